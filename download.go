@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aranaris/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+var errTooLarge = errors.New("file exceeds size limit")
+
+func autoDownloadHandler(ctx context.Context, s *state, cmd command, user database.User) error {
+	if len(cmd.arguments) != 2 {
+		return fmt.Errorf("incorrect number of arguments (expected 2)")
+	}
+
+	feed, err := getFollowedFeed(ctx, s, user, cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+
+	keepLast, err := strconv.Atoi(cmd.arguments[1])
+	if err != nil {
+		return err
+	}
+	if keepLast < 0 {
+		return fmt.Errorf("number of episodes cannot be negative")
+	}
+
+	if keepLast == 0 {
+		deleteParams := database.DeleteDownloadRuleParams{
+			UserID: user.ID,
+			FeedID: feed.ID,
+		}
+
+		_, err = s.db.DeleteDownloadRule(ctx, deleteParams)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Episodes of %s will no longer be downloaded automatically.\n", feed.Name)
+		return nil
+	}
+
+	id := uuid.New()
+	ruleParams := database.UpsertDownloadRuleParams{
+		ID:        int64(id.ID()),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+		KeepLast:  int32(keepLast),
+	}
+
+	_, err = s.db.UpsertDownloadRule(ctx, ruleParams)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("The last %d episodes of %s will be downloaded by the download command.\n", keepLast, feed.Name)
+	return nil
+}
+
+// downloadHandler downloads podcast episodes. With --feed it downloads the
+// latest --last episodes of that feed, otherwise it applies the user's
+// autodownload rules to every followed feed that has one.
+func downloadHandler(ctx context.Context, s *state, cmd command, user database.User) error {
+	fs := flag.NewFlagSet("download", flag.ContinueOnError)
+	dir := fs.String("dir", s.cfg.DownloadDir, "directory to save episodes in")
+	maxSizeMB := fs.Int64("max-size", 0, "skip episodes larger than this many megabytes (0 for no limit)")
+	feedURL := fs.String("feed", "", "download episodes of this feed instead of applying autodownload rules")
+	last := fs.Int("last", 1, "number of recent episodes to download with --feed")
+
+	args, err := cmd.parseFlags(fs)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("too many arguments")
+	}
+
+	if *dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		*dir = filepath.Join(home, "Podcasts")
+	}
+	maxSize := *maxSizeMB << 20
+
+	if *feedURL != "" {
+		feed, err := getFollowedFeed(ctx, s, user, *feedURL)
+		if err != nil {
+			return err
+		}
+		return downloadEpisodes(ctx, s, feed.ID, feed.Name, *last, *dir, maxSize)
+	}
+
+	rules, err := s.db.GetDownloadRulesForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if len(rules) == 0 {
+		fmt.Println("No autodownload rules set. Use autodownload <feed_url> <n> or download --feed <feed_url>.")
+		return nil
+	}
+
+	for _, rule := range rules {
+		err = downloadEpisodes(ctx, s, rule.FeedID, rule.FeedName, int(rule.KeepLast), *dir, maxSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getFollowedFeed looks up a feed by URL, only returning it if the user
+// follows it.
+func getFollowedFeed(ctx context.Context, s *state, user database.User, feedURL string) (database.Feed, error) {
+	feed, err := s.db.GetFeedByURL(ctx, feedURL)
+	if err != nil {
+		return database.Feed{}, err
+	}
+
+	feedFollows, err := s.db.GetFeedFollowsForUser(ctx, user.ID)
+	if err != nil {
+		return database.Feed{}, err
+	}
+	for _, ff := range feedFollows {
+		if ff.FeedID == feed.ID {
+			return feed, nil
+		}
+	}
+
+	return database.Feed{}, fmt.Errorf("you are not following %s", feed.Name)
+}
+
+func downloadEpisodes(ctx context.Context, s *state, feedID int64, feedName string, n int, dir string, maxSize int64) error {
+	episodeParams := database.GetRecentEpisodesForFeedParams{
+		FeedID: feedID,
+		Limit:  int32(n),
+	}
+
+	episodes, err := s.db.GetRecentEpisodesForFeed(ctx, episodeParams)
+	if err != nil {
+		return err
+	}
+
+	feedDir := filepath.Join(dir, safeFileName(feedName))
+	err = os.MkdirAll(feedDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	for _, ep := range episodes {
+		if ep.DownloadCompletedAt.Valid && fileExists(ep.DownloadPath.String) {
+			continue
+		}
+
+		dest := filepath.Join(feedDir, episodeFileName(ep.PostTitle, ep.Url, ep.ID))
+		fmt.Printf("Downloading %s...\n", ep.PostTitle)
+
+		size, err := downloadFile(ctx, ep.Url, dest, maxSize)
+		if errors.Is(err, errTooLarge) {
+			fmt.Printf("Skipping %s: larger than %d MB\n", ep.PostTitle, maxSize>>20)
+			continue
+		}
+
+		recordErr := recordDownload(ctx, s, ep.ID, dest, size, err == nil)
+		if err != nil {
+			return fmt.Errorf("downloading %s: %w", ep.Url, err)
+		}
+		if recordErr != nil {
+			return recordErr
+		}
+
+		fmt.Printf("Saved %s\n", dest)
+	}
+
+	return nil
+}
+
+func recordDownload(ctx context.Context, s *state, enclosureID int64, dest string, size int64, completed bool) error {
+	id := uuid.New()
+	downloadParams := database.UpsertEnclosureDownloadParams{
+		ID:          int64(id.ID()),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		EnclosureID: enclosureID,
+		Path:        dest,
+		Bytes:       size,
+		CompletedAt: sql.NullTime{
+			Time:  time.Now(),
+			Valid: completed,
+		},
+	}
+
+	return s.db.UpsertEnclosureDownload(ctx, downloadParams)
+}
+
+// downloadFile saves url to dest, resuming from dest.part if an earlier
+// attempt was interrupted. It returns the number of bytes on disk.
+func downloadFile(ctx context.Context, fileURL, dest string, maxSize int64) (int64, error) {
+	partPath := dest + ".part"
+
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fileURL, nil)
+	if err != nil {
+		return offset, err
+	}
+	req.Header.Add("User-Agent", "gator")
+	if offset > 0 {
+		req.Header.Add("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return offset, err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The partial file already holds the whole body.
+		return offset, os.Rename(partPath, dest)
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		// The server ignored the range, so start over.
+		offset = 0
+		flags |= os.O_TRUNC
+	default:
+		return offset, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	if maxSize > 0 && resp.ContentLength > 0 && offset+resp.ContentLength > maxSize {
+		return offset, errTooLarge
+	}
+
+	f, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return offset, err
+	}
+	defer f.Close()
+
+	body := io.Reader(resp.Body)
+	if maxSize > 0 {
+		body = io.LimitReader(resp.Body, maxSize-offset+1)
+	}
+
+	written, err := io.Copy(f, body)
+	size := offset + written
+	if err != nil {
+		return size, err
+	}
+	if maxSize > 0 && size > maxSize {
+		f.Close()
+		os.Remove(partPath)
+		return 0, errTooLarge
+	}
+
+	err = f.Close()
+	if err != nil {
+		return size, err
+	}
+
+	return size, os.Rename(partPath, dest)
+}
+
+// episodeFileName builds a file name from the post title and the enclosure
+// id, keeping the extension of the enclosure URL. The id keeps episodes that
+// share a title, such as repeated "Bonus" episodes, from overwriting each
+// other or resuming each other's partial downloads.
+func episodeFileName(title, fileURL string, enclosureID int64) string {
+	base := "episode"
+	if u, err := url.Parse(fileURL); err == nil {
+		base = path.Base(u.Path)
+	}
+	ext := path.Ext(base)
+
+	name := safeFileName(title)
+	if name == "" {
+		name = safeFileName(strings.TrimSuffix(base, ext))
+	}
+	if name == "" {
+		name = "episode"
+	}
+	return fmt.Sprintf("%s-%d%s", name, enclosureID, safeFileName(ext))
+}
+
+func safeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '-'
+		}
+		if r < 32 {
+			return -1
+		}
+		return r
+	}, name)
+	return strings.Trim(strings.TrimSpace(name), ".")
+}
+
+func fileExists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
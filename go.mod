@@ -3,13 +3,15 @@ module github.com/aranaris/gator
 go 1.22.5
 
 require internal/config v1.0.0
-require internal/rss v1.0.0
 
 require (
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
-	github.com/lib/pq v1.10.9 // indirect
+	github.com/google/uuid v1.6.0
+	github.com/joho/godotenv v1.5.1
+	github.com/lib/pq v1.10.9
+	golang.org/x/net v0.35.0
+	internal/rss v1.0.0
 )
 
 replace internal/config => ./internal/config
+
 replace internal/rss => ./internal/rss
@@ -12,6 +12,7 @@ const ConfigFileName = ".gatorconfig.json"
 type Config struct {
 	DBurl string `json:"db_url"`
 	CurrentUser string `json:"current_user_name"`
+	DownloadDir string `json:"download_dir,omitempty"`
 }
 
 func Read() (Config, error) {
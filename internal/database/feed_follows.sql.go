@@ -7,22 +7,24 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 )
 
 const createFeedFollow = `-- name: CreateFeedFollow :one
-with inserted as (INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+with inserted as (INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, folder)
 VALUES (
 	$1,
 	$2,
 	$3,
 	$4,
-	$5
+	$5,
+	$6
 )
-RETURNING id, created_at, updated_at, user_id, feed_id)
+RETURNING id, created_at, updated_at, user_id, feed_id, folder)
 
 SELECT
-	inserted.id, inserted.created_at, inserted.updated_at, inserted.user_id, inserted.feed_id,
+	inserted.id, inserted.created_at, inserted.updated_at, inserted.user_id, inserted.feed_id, inserted.folder,
 	feeds.name feed_name,
 	users.name user_name
 FROM
@@ -37,6 +39,7 @@ type CreateFeedFollowParams struct {
 	UpdatedAt time.Time
 	UserID    int64
 	FeedID    int64
+	Folder    sql.NullString
 }
 
 type CreateFeedFollowRow struct {
@@ -45,6 +48,7 @@ type CreateFeedFollowRow struct {
 	UpdatedAt time.Time
 	UserID    int64
 	FeedID    int64
+	Folder    sql.NullString
 	FeedName  string
 	UserName  string
 }
@@ -56,6 +60,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		arg.UpdatedAt,
 		arg.UserID,
 		arg.FeedID,
+		arg.Folder,
 	)
 	var i CreateFeedFollowRow
 	err := row.Scan(
@@ -64,6 +69,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.Folder,
 		&i.FeedName,
 		&i.UserName,
 	)
@@ -75,7 +81,7 @@ DELETE FROM feed_follows
 WHERE
 	feed_follows.user_id = $1
 	and feed_follows.feed_id = $2
-RETURNING id, created_at, updated_at, user_id, feed_id
+RETURNING id, created_at, updated_at, user_id, feed_id, folder
 `
 
 type DeleteFeedFollowParams struct {
@@ -92,14 +98,17 @@ func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.Folder,
 	)
 	return i, err
 }
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
 SELECT
-	feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id,
-	feeds.name feed_name
+	feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id, feed_follows.folder,
+	feeds.name feed_name,
+	feeds.url feed_url,
+	feeds.site_url feed_site_url
 FROM
 	feed_follows
 	JOIN feeds on feed_follows.feed_id = feeds.id
@@ -108,12 +117,15 @@ WHERE
 `
 
 type GetFeedFollowsForUserRow struct {
-	ID        int64
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    int64
-	FeedID    int64
-	FeedName  string
+	ID          int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      int64
+	FeedID      int64
+	Folder      sql.NullString
+	FeedName    string
+	FeedUrl     string
+	FeedSiteUrl sql.NullString
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID int64) ([]GetFeedFollowsForUserRow, error) {
@@ -131,7 +143,10 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID int64) ([]Ge
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.FeedID,
+			&i.Folder,
 			&i.FeedName,
+			&i.FeedUrl,
+			&i.FeedSiteUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -7,29 +7,108 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 )
 
+const claimFeedsToFetch = `-- name: ClaimFeedsToFetch :many
+UPDATE feeds
+SET updated_at = current_timestamp, last_fetched_at = current_timestamp,
+	claimed_until = current_timestamp + make_interval(secs => $1::float8)
+WHERE feeds.id IN (
+	SELECT id FROM feeds
+	WHERE
+		disabled_at IS NULL
+		AND (claimed_until IS NULL OR claimed_until <= current_timestamp)
+		AND (
+			consecutive_failures = 0
+			OR last_fetched_at IS NULL
+			OR last_fetched_at + make_interval(secs => $2::float8 * power(2, least(consecutive_failures, 10))) <= current_timestamp
+		)
+	ORDER BY feeds.last_fetched_at ASC NULLS FIRST
+	LIMIT $3
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, last_error, consecutive_failures, last_succeeded_at, disabled_at, description, site_url, image_url, claimed_until
+`
+
+type ClaimFeedsToFetchParams struct {
+	LeaseSeconds   float64
+	BackoffSeconds float64
+	MaxFeeds       int32
+}
+
+// Claimed feeds are leased for lease_seconds so other agg processes skip
+// them until the fetch has been recorded or the lease has run out. Feeds
+// that keep failing are retried after an exponentially growing delay of
+// backoff_seconds * 2^failures, capped at 2^10.
+func (q *Queries) ClaimFeedsToFetch(ctx context.Context, arg ClaimFeedsToFetchParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, claimFeedsToFetch, arg.LeaseSeconds, arg.BackoffSeconds, arg.MaxFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.LastError,
+			&i.ConsecutiveFailures,
+			&i.LastSucceededAt,
+			&i.DisabledAt,
+			&i.Description,
+			&i.SiteUrl,
+			&i.ImageUrl,
+			&i.ClaimedUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createFeed = `-- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, description, site_url, image_url)
 VALUES (
 	$1,
 	$2,
 	$3,
 	$4,
 	$5,
-	$6
+	$6,
+	$7,
+	$8,
+	$9
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, last_error, consecutive_failures, last_succeeded_at, disabled_at, description, site_url, image_url, claimed_until
 `
 
 type CreateFeedParams struct {
-	ID        int64
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
-	Url       string
-	UserID    int64
+	ID          int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string
+	Url         string
+	UserID      int64
+	Description sql.NullString
+	SiteUrl     sql.NullString
+	ImageUrl    sql.NullString
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -40,7 +119,74 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.Description,
+		arg.SiteUrl,
+		arg.ImageUrl,
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.LastSucceededAt,
+		&i.DisabledAt,
+		&i.Description,
+		&i.SiteUrl,
+		&i.ImageUrl,
+		&i.ClaimedUntil,
+	)
+	return i, err
+}
+
+const disableFeed = `-- name: DisableFeed :one
+UPDATE feeds
+SET updated_at = current_timestamp, disabled_at = current_timestamp
+WHERE feeds.id = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, last_error, consecutive_failures, last_succeeded_at, disabled_at, description, site_url, image_url, claimed_until
+`
+
+func (q *Queries) DisableFeed(ctx context.Context, id int64) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, disableFeed, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.LastSucceededAt,
+		&i.DisabledAt,
+		&i.Description,
+		&i.SiteUrl,
+		&i.ImageUrl,
+		&i.ClaimedUntil,
 	)
+	return i, err
+}
+
+const enableFeed = `-- name: EnableFeed :one
+UPDATE feeds
+SET updated_at = current_timestamp, disabled_at = NULL, consecutive_failures = 0
+WHERE feeds.id = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, last_error, consecutive_failures, last_succeeded_at, disabled_at, description, site_url, image_url, claimed_until
+`
+
+func (q *Queries) EnableFeed(ctx context.Context, id int64) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, enableFeed, id)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -50,12 +196,22 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.LastSucceededAt,
+		&i.DisabledAt,
+		&i.Description,
+		&i.SiteUrl,
+		&i.ImageUrl,
+		&i.ClaimedUntil,
 	)
 	return i, err
 }
 
 const getFeedByURL = `-- name: GetFeedByURL :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds where url = $1
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, last_error, consecutive_failures, last_succeeded_at, disabled_at, description, site_url, image_url, claimed_until FROM feeds where url = $1
 `
 
 func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
@@ -69,12 +225,22 @@ func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.LastSucceededAt,
+		&i.DisabledAt,
+		&i.Description,
+		&i.SiteUrl,
+		&i.ImageUrl,
+		&i.ClaimedUntil,
 	)
 	return i, err
 }
 
 const getFeeds = `-- name: GetFeeds :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, last_error, consecutive_failures, last_succeeded_at, disabled_at, description, site_url, image_url, claimed_until FROM feeds
 `
 
 func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
@@ -94,6 +260,63 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.LastError,
+			&i.ConsecutiveFailures,
+			&i.LastSucceededAt,
+			&i.DisabledAt,
+			&i.Description,
+			&i.SiteUrl,
+			&i.ImageUrl,
+			&i.ClaimedUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedsWithErrors = `-- name: GetFeedsWithErrors :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, last_error, consecutive_failures, last_succeeded_at, disabled_at, description, site_url, image_url, claimed_until FROM feeds
+WHERE consecutive_failures > 0
+ORDER BY consecutive_failures DESC, name
+`
+
+func (q *Queries) GetFeedsWithErrors(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsWithErrors)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.LastError,
+			&i.ConsecutiveFailures,
+			&i.LastSucceededAt,
+			&i.DisabledAt,
+			&i.Description,
+			&i.SiteUrl,
+			&i.ImageUrl,
+			&i.ClaimedUntil,
 		); err != nil {
 			return nil, err
 		}
@@ -108,18 +331,15 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 	return items, nil
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
-FROM
-(
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds 
-ORDER BY feeds.last_fetched_at ASC NULLS FIRST 
-) as f
-LIMIT 1
+const markFeedFetched = `-- name: MarkFeedFetched :one
+UPDATE feeds
+SET updated_at = current_timestamp, last_fetched_at = current_timestamp
+WHERE feeds.id = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, last_error, consecutive_failures, last_succeeded_at, disabled_at, description, site_url, image_url, claimed_until
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
+func (q *Queries) MarkFeedFetched(ctx context.Context, id int64) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, markFeedFetched, id)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -129,19 +349,34 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.LastSucceededAt,
+		&i.DisabledAt,
+		&i.Description,
+		&i.SiteUrl,
+		&i.ImageUrl,
+		&i.ClaimedUntil,
 	)
 	return i, err
 }
 
-const markFeedFetched = `-- name: MarkFeedFetched :one
+const recordFeedFailure = `-- name: RecordFeedFailure :one
 UPDATE feeds
-SET updated_at = current_timestamp, last_fetched_at = current_timestamp
+SET last_error = $2, consecutive_failures = consecutive_failures + 1, claimed_until = NULL
 WHERE feeds.id = $1
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, last_error, consecutive_failures, last_succeeded_at, disabled_at, description, site_url, image_url, claimed_until
 `
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, id int64) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, markFeedFetched, id)
+type RecordFeedFailureParams struct {
+	ID        int64
+	LastError sql.NullString
+}
+
+func (q *Queries) RecordFeedFailure(ctx context.Context, arg RecordFeedFailureParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, recordFeedFailure, arg.ID, arg.LastError)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -151,6 +386,44 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id int64) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.LastSucceededAt,
+		&i.DisabledAt,
+		&i.Description,
+		&i.SiteUrl,
+		&i.ImageUrl,
+		&i.ClaimedUntil,
 	)
 	return i, err
 }
+
+const recordFeedSuccess = `-- name: RecordFeedSuccess :exec
+UPDATE feeds
+SET last_error = NULL, consecutive_failures = 0, last_succeeded_at = current_timestamp, claimed_until = NULL
+WHERE feeds.id = $1
+`
+
+func (q *Queries) RecordFeedSuccess(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, recordFeedSuccess, id)
+	return err
+}
+
+const updateFeedCacheValidators = `-- name: UpdateFeedCacheValidators :exec
+UPDATE feeds
+SET etag = $2, last_modified = $3
+WHERE feeds.id = $1
+`
+
+type UpdateFeedCacheValidatorsParams struct {
+	ID           int64
+	Etag         sql.NullString
+	LastModified sql.NullString
+}
+
+func (q *Queries) UpdateFeedCacheValidators(ctx context.Context, arg UpdateFeedCacheValidatorsParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedCacheValidators, arg.ID, arg.Etag, arg.LastModified)
+	return err
+}
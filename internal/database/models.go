@@ -9,14 +9,43 @@ import (
 	"time"
 )
 
+type DownloadRule struct {
+	ID        int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    int64
+	FeedID    int64
+	KeepLast  int32
+}
+
+type EnclosureDownload struct {
+	ID          int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	EnclosureID int64
+	Path        string
+	Bytes       int64
+	CompletedAt sql.NullTime
+}
+
 type Feed struct {
-	ID            int64
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        int64
-	LastFetchedAt sql.NullTime
+	ID                  int64
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Name                string
+	Url                 string
+	UserID              int64
+	LastFetchedAt       sql.NullTime
+	Etag                sql.NullString
+	LastModified        sql.NullString
+	LastError           sql.NullString
+	ConsecutiveFailures int32
+	LastSucceededAt     sql.NullTime
+	DisabledAt          sql.NullTime
+	Description         sql.NullString
+	SiteUrl             sql.NullString
+	ImageUrl            sql.NullString
+	ClaimedUntil        sql.NullTime
 }
 
 type FeedFollow struct {
@@ -25,17 +54,54 @@ type FeedFollow struct {
 	UpdatedAt time.Time
 	UserID    int64
 	FeedID    int64
+	Folder    sql.NullString
 }
 
 type Post struct {
+	ID                   int64
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Title                string
+	Url                  string
+	Description          sql.NullString
+	PublishedAt          time.Time
+	FeedID               int64
+	PublishedAtEstimated bool
+	Guid                 string
+	ContentHash          string
+	RevisedAt            sql.NullTime
+	Content              sql.NullString
+	SearchVector         interface{}
+}
+
+type PostEnclosure struct {
+	ID        int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	PostID    int64
+	Url       string
+	Length    sql.NullInt64
+	MimeType  sql.NullString
+	Duration  sql.NullString
+	Episode   sql.NullInt32
+	Season    sql.NullInt32
+	ImageUrl  sql.NullString
+}
+
+type PostRead struct {
+	UserID int64
+	PostID int64
+	ReadAt time.Time
+}
+
+type PostRevision struct {
 	ID          int64
 	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	PostID      int64
 	Title       string
 	Url         string
 	Description sql.NullString
-	PublishedAt time.Time
-	FeedID      int64
+	Content     sql.NullString
 }
 
 type User struct {
@@ -44,3 +110,9 @@ type User struct {
 	UpdatedAt time.Time
 	Name      string
 }
+
+type UserPostState struct {
+	UserID    int64
+	PostID    int64
+	StarredAt sql.NullTime
+}
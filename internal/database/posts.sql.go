@@ -11,8 +11,157 @@ import (
 	"time"
 )
 
-const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+const backfillPostContent = `-- name: BackfillPostContent :exec
+UPDATE posts
+SET content = $2, content_hash = $3, description = $4
+WHERE posts.id = $1
+`
+
+type BackfillPostContentParams struct {
+	ID          int64
+	Content     sql.NullString
+	ContentHash string
+	Description sql.NullString
+}
+
+// Fills in fields that were not recorded when the post was first saved,
+// and the sanitized description, without treating the post as revised.
+func (q *Queries) BackfillPostContent(ctx context.Context, arg BackfillPostContentParams) error {
+	_, err := q.db.ExecContext(ctx, backfillPostContent,
+		arg.ID,
+		arg.Content,
+		arg.ContentHash,
+		arg.Description,
+	)
+	return err
+}
+
+const browsePostsForUser = `-- name: BrowsePostsForUser :many
+SELECT
+	posts.id,
+	posts.created_at,
+	posts.updated_at,
+	posts.title,
+	posts.url,
+	posts.description,
+	posts.published_at,
+	posts.feed_id,
+	posts.published_at_estimated,
+	posts.guid,
+	posts.content_hash,
+	posts.revised_at,
+	posts.content
+FROM
+	posts
+	JOIN feeds ON posts.feed_id = feeds.id
+	LEFT JOIN user_post_state ON user_post_state.post_id = posts.id
+		AND user_post_state.user_id = $1
+WHERE
+	(
+		($2::bool AND user_post_state.starred_at IS NOT NULL)
+		OR (NOT $2::bool AND EXISTS (
+			SELECT 1 FROM feed_follows
+			WHERE feed_follows.feed_id = posts.feed_id
+				AND feed_follows.user_id = $1
+		))
+	)
+	AND (NOT $3::bool OR NOT EXISTS (
+		SELECT 1 FROM post_reads
+		WHERE post_reads.user_id = $1
+			AND post_reads.post_id = posts.id
+	))
+	AND ($4::text IS NULL OR feeds.url = $4)
+	AND ($5::timestamp IS NULL OR posts.published_at >= $5)
+	AND ($6::timestamp IS NULL OR posts.published_at < $6)
+ORDER BY
+	CASE WHEN $2::bool AND NOT $7::bool THEN user_post_state.starred_at END DESC,
+	CASE WHEN $7::bool THEN posts.published_at END ASC,
+	posts.published_at DESC,
+	posts.id
+LIMIT $8::int
+OFFSET $9::int
+`
+
+type BrowsePostsForUserParams struct {
+	UserID      int64
+	Starred     bool
+	Unread      bool
+	FeedUrl     sql.NullString
+	Since       sql.NullTime
+	Until       sql.NullTime
+	OldestFirst bool
+	PostLimit   sql.NullInt32
+	PostOffset  int32
+}
+
+type BrowsePostsForUserRow struct {
+	ID                   int64
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Title                string
+	Url                  string
+	Description          sql.NullString
+	PublishedAt          time.Time
+	FeedID               int64
+	PublishedAtEstimated bool
+	Guid                 string
+	ContentHash          string
+	RevisedAt            sql.NullTime
+	Content              sql.NullString
+}
+
+// Posts in the user's followed feeds, or their starred posts in any feed,
+// narrowed by the optional filters. Starred posts come most recently starred
+// first unless oldest_first is set. A null post_limit returns every post.
+func (q *Queries) BrowsePostsForUser(ctx context.Context, arg BrowsePostsForUserParams) ([]BrowsePostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, browsePostsForUser,
+		arg.UserID,
+		arg.Starred,
+		arg.Unread,
+		arg.FeedUrl,
+		arg.Since,
+		arg.Until,
+		arg.OldestFirst,
+		arg.PostLimit,
+		arg.PostOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BrowsePostsForUserRow
+	for rows.Next() {
+		var i BrowsePostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.PublishedAtEstimated,
+			&i.Guid,
+			&i.ContentHash,
+			&i.RevisedAt,
+			&i.Content,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createPost = `-- name: CreatePost :execrows
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, published_at_estimated, guid, content_hash, content)
 VALUES (
     $1,
     $2,
@@ -21,24 +170,32 @@ VALUES (
 		$5,
 		$6,
 		$7,
-		$8
+		$8,
+		$9,
+		$10,
+		$11,
+		$12
 )
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+ON CONFLICT (feed_id, guid) DO NOTHING
 `
 
 type CreatePostParams struct {
-	ID          int64
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt time.Time
-	FeedID      int64
+	ID                   int64
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Title                string
+	Url                  string
+	Description          sql.NullString
+	PublishedAt          time.Time
+	FeedID               int64
+	PublishedAtEstimated bool
+	Guid                 string
+	ContentHash          string
+	Content              sql.NullString
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
-	row := q.db.QueryRowContext(ctx, createPost,
+func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createPost,
 		arg.ID,
 		arg.CreatedAt,
 		arg.UpdatedAt,
@@ -47,8 +204,80 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.PublishedAtEstimated,
+		arg.Guid,
+		arg.ContentHash,
+		arg.Content,
 	)
-	var i Post
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getLegacyPostByFeedAndURL = `-- name: GetLegacyPostByFeedAndURL :one
+SELECT id FROM posts
+WHERE feed_id = $1 AND guid = url AND url = $2
+`
+
+type GetLegacyPostByFeedAndURLParams struct {
+	FeedID int64
+	Url    string
+}
+
+// Posts saved before guids were recorded had their url copied into guid by
+// migration 010.
+func (q *Queries) GetLegacyPostByFeedAndURL(ctx context.Context, arg GetLegacyPostByFeedAndURLParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getLegacyPostByFeedAndURL, arg.FeedID, arg.Url)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getPostByFeedAndGuid = `-- name: GetPostByFeedAndGuid :one
+SELECT
+	posts.id,
+	posts.created_at,
+	posts.updated_at,
+	posts.title,
+	posts.url,
+	posts.description,
+	posts.published_at,
+	posts.feed_id,
+	posts.published_at_estimated,
+	posts.guid,
+	posts.content_hash,
+	posts.revised_at,
+	posts.content
+FROM posts
+WHERE feed_id = $1 AND guid = $2
+`
+
+type GetPostByFeedAndGuidParams struct {
+	FeedID int64
+	Guid   string
+}
+
+type GetPostByFeedAndGuidRow struct {
+	ID                   int64
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Title                string
+	Url                  string
+	Description          sql.NullString
+	PublishedAt          time.Time
+	FeedID               int64
+	PublishedAtEstimated bool
+	Guid                 string
+	ContentHash          string
+	RevisedAt            sql.NullTime
+	Content              sql.NullString
+}
+
+// Posts are read column by column so search_vector stays in the database.
+func (q *Queries) GetPostByFeedAndGuid(ctx context.Context, arg GetPostByFeedAndGuidParams) (GetPostByFeedAndGuidRow, error) {
+	row := q.db.QueryRowContext(ctx, getPostByFeedAndGuid, arg.FeedID, arg.Guid)
+	var i GetPostByFeedAndGuidRow
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
@@ -58,51 +287,175 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.PublishedAtEstimated,
+		&i.Guid,
+		&i.ContentHash,
+		&i.RevisedAt,
+		&i.Content,
 	)
 	return i, err
 }
 
-const getPostsForUser = `-- name: GetPostsForUser :many
+const getPostForUser = `-- name: GetPostForUser :one
 SELECT
-	id, created_at, updated_at, title, url, description, published_at, feed_id
+	posts.id,
+	posts.created_at,
+	posts.updated_at,
+	posts.title,
+	posts.url,
+	posts.description,
+	posts.published_at,
+	posts.feed_id,
+	posts.published_at_estimated,
+	posts.guid,
+	posts.content_hash,
+	posts.revised_at,
+	posts.content
 FROM
-	(
-		SELECT
-			posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id
-		FROM
-			posts
-			JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
-		WHERE
-			feed_follows.user_id = $1
-		ORDER BY
-			posts.published_at DESC
-	) as p
-LIMIT $2
+	posts
+WHERE
+	(posts.id = $1 OR posts.url = $2)
+	AND (
+		EXISTS (
+			SELECT 1 FROM feed_follows
+			WHERE feed_follows.feed_id = posts.feed_id
+				AND feed_follows.user_id = $3
+		)
+		OR EXISTS (
+			SELECT 1 FROM user_post_state
+			WHERE user_post_state.post_id = posts.id
+				AND user_post_state.user_id = $3
+				AND user_post_state.starred_at IS NOT NULL
+		)
+	)
+ORDER BY
+	posts.published_at DESC
+LIMIT 1
 `
 
-type GetPostsForUserParams struct {
+type GetPostForUserParams struct {
+	PostID int64
+	Url    string
 	UserID int64
-	Limit  int32
 }
 
-func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+type GetPostForUserRow struct {
+	ID                   int64
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Title                string
+	Url                  string
+	Description          sql.NullString
+	PublishedAt          time.Time
+	FeedID               int64
+	PublishedAtEstimated bool
+	Guid                 string
+	ContentHash          string
+	RevisedAt            sql.NullTime
+	Content              sql.NullString
+}
+
+// Posts in feeds the user follows, plus any post they starred.
+func (q *Queries) GetPostForUser(ctx context.Context, arg GetPostForUserParams) (GetPostForUserRow, error) {
+	row := q.db.QueryRowContext(ctx, getPostForUser, arg.PostID, arg.Url, arg.UserID)
+	var i GetPostForUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.PublishedAtEstimated,
+		&i.Guid,
+		&i.ContentHash,
+		&i.RevisedAt,
+		&i.Content,
+	)
+	return i, err
+}
+
+const searchPostsForUser = `-- name: SearchPostsForUser :many
+SELECT
+	posts.id,
+	posts.title,
+	posts.url,
+	posts.published_at,
+	posts.published_at_estimated,
+	feeds.name feed_name,
+	ts_rank(posts.search_vector, websearch_to_tsquery('english', $1)) rank,
+	ts_headline(
+		'english',
+		regexp_replace(coalesce(posts.description, ''), '<[^>]*>', ' ', 'g'),
+		websearch_to_tsquery('english', $1),
+		'StartSel=**, StopSel=**, MaxFragments=2, MaxWords=25, MinWords=10'
+	) snippet
+FROM
+	posts
+	JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+	JOIN feeds ON posts.feed_id = feeds.id
+WHERE
+	feed_follows.user_id = $2
+	AND posts.search_vector @@ websearch_to_tsquery('english', $1)
+	AND ($3::text IS NULL OR feeds.url = $3)
+	AND ($4::timestamp IS NULL OR posts.published_at >= $4)
+	AND ($5::timestamp IS NULL OR posts.published_at < $5)
+ORDER BY
+	rank DESC,
+	posts.published_at DESC
+LIMIT $6
+`
+
+type SearchPostsForUserParams struct {
+	Query      string
+	UserID     int64
+	FeedUrl    sql.NullString
+	Since      sql.NullTime
+	Until      sql.NullTime
+	MaxResults int32
+}
+
+type SearchPostsForUserRow struct {
+	ID                   int64
+	Title                string
+	Url                  string
+	PublishedAt          time.Time
+	PublishedAtEstimated bool
+	FeedName             string
+	Rank                 float32
+	Snippet              string
+}
+
+// Ranks matching posts in the user's followed feeds, title matches first.
+// The snippet is taken from the description with its markup removed and
+// matched words wrapped in **.
+func (q *Queries) SearchPostsForUser(ctx context.Context, arg SearchPostsForUserParams) ([]SearchPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPostsForUser,
+		arg.Query,
+		arg.UserID,
+		arg.FeedUrl,
+		arg.Since,
+		arg.Until,
+		arg.MaxResults,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Post
+	var items []SearchPostsForUserRow
 	for rows.Next() {
-		var i Post
+		var i SearchPostsForUserRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
 			&i.Title,
 			&i.Url,
-			&i.Description,
 			&i.PublishedAt,
-			&i.FeedID,
+			&i.PublishedAtEstimated,
+			&i.FeedName,
+			&i.Rank,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
@@ -116,3 +469,65 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const updatePostContent = `-- name: UpdatePostContent :exec
+WITH revision AS (
+	INSERT INTO post_revisions (id, created_at, post_id, title, url, description, content)
+	SELECT $1, $2, posts.id, posts.title, posts.url, posts.description, posts.content
+	FROM posts
+	WHERE posts.id = $3
+)
+UPDATE posts
+SET
+	title = $4,
+	url = $5,
+	description = $6,
+	content = $7,
+	content_hash = $8,
+	updated_at = $2,
+	revised_at = $2
+WHERE posts.id = $3
+`
+
+type UpdatePostContentParams struct {
+	RevisionID  int64
+	RevisedAt   time.Time
+	PostID      int64
+	Title       string
+	Url         string
+	Description sql.NullString
+	Content     sql.NullString
+	ContentHash string
+}
+
+// Saves the current title, url, description and content as a revision
+// before overwriting them.
+func (q *Queries) UpdatePostContent(ctx context.Context, arg UpdatePostContentParams) error {
+	_, err := q.db.ExecContext(ctx, updatePostContent,
+		arg.RevisionID,
+		arg.RevisedAt,
+		arg.PostID,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.Content,
+		arg.ContentHash,
+	)
+	return err
+}
+
+const updatePostGuid = `-- name: UpdatePostGuid :exec
+UPDATE posts
+SET updated_at = current_timestamp, guid = $2
+WHERE posts.id = $1
+`
+
+type UpdatePostGuidParams struct {
+	ID   int64
+	Guid string
+}
+
+func (q *Queries) UpdatePostGuid(ctx context.Context, arg UpdatePostGuidParams) error {
+	_, err := q.db.ExecContext(ctx, updatePostGuid, arg.ID, arg.Guid)
+	return err
+}
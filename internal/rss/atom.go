@@ -0,0 +1,109 @@
+package rss
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+type atomFeed struct {
+	Title    atomText    `xml:"title"`
+	Subtitle atomText    `xml:"subtitle"`
+	Links    []atomLink  `xml:"link"`
+	Logo     string      `xml:"logo"`
+	Icon     string      `xml:"icon"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     atomText   `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Summary   atomText   `xml:"summary"`
+	Content   atomText   `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// atomText holds a text construct, which may be plain text, escaped html
+// or inline xhtml depending on its type attribute.
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+func parseAtom(data []byte) (*RSSFeed, error) {
+	af := atomFeed{}
+	err := xml.Unmarshal(data, &af)
+	if err != nil {
+		return nil, err
+	}
+
+	rf := RSSFeed{}
+	rf.Channel.Title = af.Title.String()
+	rf.Channel.Link = alternateLink(af.Links)
+	rf.Channel.Description = af.Subtitle.String()
+	rf.Channel.Images = []Image{{URL: af.Logo}, {URL: af.Icon}}
+
+	for _, e := range af.Entries {
+		description := e.Summary.String()
+		if description == "" {
+			description = e.Content.String()
+		}
+
+		date := e.Published
+		if date == "" {
+			date = e.Updated
+		}
+
+		item := RSSItem{
+			Title:       e.Title.String(),
+			Link:        alternateLink(e.Links),
+			Description: description,
+			Content:     e.Content.String(),
+			PubDate:     normalizeDate(date),
+			GUID:        strings.TrimSpace(e.ID),
+		}
+		for _, l := range e.Links {
+			if l.Rel == "enclosure" {
+				item.Enclosures = append(item.Enclosures, Enclosure{
+					URL:    l.Href,
+					Length: l.Length,
+					Type:   l.Type,
+				})
+			}
+		}
+
+		rf.Channel.Item = append(rf.Channel.Item, item)
+	}
+
+	return &rf, nil
+}
+
+// alternateLink returns the rel="alternate" link, which is also the default
+// when rel is omitted. Falls back to the first link present.
+func alternateLink(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	if len(links) > 0 {
+		return links[0].Href
+	}
+	return ""
+}
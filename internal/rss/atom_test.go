@@ -0,0 +1,119 @@
+package rss
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFeedAtom(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		wantLink string
+		want     []RSSItem
+	}{
+		{
+			name: "alternate and self links",
+			in: `<feed xmlns="http://www.w3.org/2005/Atom">` +
+				`<link rel="self" href="https://example.com/atom.xml"/>` +
+				`<link rel="alternate" href="https://example.com/"/>` +
+				`<entry><id>tag:example.com,2024:1</id><title>One</title>` +
+				`<link rel="self" href="https://example.com/1.atom"/>` +
+				`<link href="https://example.com/1"/>` +
+				`<published>2024-01-02T03:04:05Z</published></entry>` +
+				`</feed>`,
+			wantLink: "https://example.com/",
+			want: []RSSItem{{
+				Title:   "One",
+				Link:    "https://example.com/1",
+				PubDate: "Tue, 02 Jan 2024 03:04:05 +0000",
+				GUID:    "tag:example.com,2024:1",
+			}},
+		},
+		{
+			name: "only self link",
+			in: `<feed xmlns="http://www.w3.org/2005/Atom">` +
+				`<link rel="self" href="https://example.com/atom.xml"/>` +
+				`<entry><id>1</id><link rel="related" href="https://other.example/"/></entry>` +
+				`</feed>`,
+			wantLink: "https://example.com/atom.xml",
+			want: []RSSItem{{
+				Link: "https://other.example/",
+				GUID: "1",
+			}},
+		},
+		{
+			name: "html and xhtml text",
+			in: `<feed xmlns="http://www.w3.org/2005/Atom"><entry><id>1</id>` +
+				`<title type="html">Fish &amp;amp; Chips</title>` +
+				`<summary type="html">&lt;p&gt;Short&lt;/p&gt;</summary>` +
+				`<content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Long</p></div></content>` +
+				`</entry></feed>`,
+			want: []RSSItem{{
+				Title:       "Fish &amp; Chips",
+				Description: "<p>Short</p>",
+				Content:     `<div xmlns="http://www.w3.org/1999/xhtml"><p>Long</p></div>`,
+				GUID:        "1",
+			}},
+		},
+		{
+			name: "content without summary",
+			in: `<feed xmlns="http://www.w3.org/2005/Atom"><entry><id>1</id>` +
+				`<content type="html">&lt;p&gt;Body&lt;/p&gt;</content>` +
+				`</entry></feed>`,
+			want: []RSSItem{{
+				Description: "<p>Body</p>",
+				Content:     "<p>Body</p>",
+				GUID:        "1",
+			}},
+		},
+		{
+			name: "updated when not published",
+			in: `<feed xmlns="http://www.w3.org/2005/Atom"><entry><id>1</id>` +
+				`<updated>2024-01-02T05:04:05+02:00</updated>` +
+				`</entry></feed>`,
+			want: []RSSItem{{
+				PubDate: "Tue, 02 Jan 2024 05:04:05 +0200",
+				GUID:    "1",
+			}},
+		},
+		{
+			name: "published preferred over updated",
+			in: `<feed xmlns="http://www.w3.org/2005/Atom"><entry><id>1</id>` +
+				`<published>2024-01-01T00:00:00Z</published>` +
+				`<updated>2024-01-02T00:00:00Z</updated>` +
+				`</entry></feed>`,
+			want: []RSSItem{{
+				PubDate: "Mon, 01 Jan 2024 00:00:00 +0000",
+				GUID:    "1",
+			}},
+		},
+		{
+			name: "enclosure link",
+			in: `<feed xmlns="http://www.w3.org/2005/Atom"><entry><id>1</id>` +
+				`<link href="https://example.com/1"/>` +
+				`<link rel="enclosure" href="https://example.com/1.mp3" type="audio/mpeg" length="123"/>` +
+				`</entry></feed>`,
+			want: []RSSItem{{
+				Link:       "https://example.com/1",
+				GUID:       "1",
+				Enclosures: []Enclosure{{URL: "https://example.com/1.mp3", Length: "123", Type: "audio/mpeg"}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rf, err := parseFeed([]byte(tt.in), "application/atom+xml")
+			if err != nil {
+				t.Fatalf("parseFeed returned error: %v", err)
+			}
+			if rf.Channel.Link != tt.wantLink {
+				t.Errorf("Channel.Link = %q, want %q", rf.Channel.Link, tt.wantLink)
+			}
+			if !reflect.DeepEqual(rf.Channel.Item, tt.want) {
+				t.Errorf("items =\n%+v\nwant\n%+v", rf.Channel.Item, tt.want)
+			}
+		})
+	}
+}
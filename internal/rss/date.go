@@ -0,0 +1,95 @@
+package rss
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// dateLayouts are tried in order after the weekday has been stripped and
+// named zones have been replaced by numeric offsets.
+var dateLayouts = []string{
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 -07:00",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04 -0700",
+	"2 January 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006",
+	"Jan 2 2006 15:04:05 -0700",
+	"Jan 2 15:04:05 2006",
+	"Jan 2 2006",
+	"January 2 2006",
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// zoneOffsets maps the zone names allowed by RFC 822 (plus UTC) to numeric
+// offsets. time.Parse would otherwise accept them with a zero offset.
+var zoneOffsets = map[string]string{
+	"UT":  "+0000",
+	"UTC": "+0000",
+	"GMT": "+0000",
+	"Z":   "+0000",
+	"EST": "-0500",
+	"EDT": "-0400",
+	"CST": "-0600",
+	"CDT": "-0500",
+	"MST": "-0700",
+	"MDT": "-0600",
+	"PST": "-0800",
+	"PDT": "-0700",
+}
+
+var weekdayPrefix = regexp.MustCompile(`^[A-Za-z]+,\s*`)
+
+// ParseDate parses the date formats found in real-world feeds: RFC 1123 and
+// RFC 822 with numeric or named zones, two-digit years, RFC 3339 and other
+// ISO 8601 variants. The result is in UTC, because the database stores
+// timestamps without their zone.
+func ParseDate(s string) (time.Time, error) {
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		return time.Time{}, fmt.Errorf("empty date")
+	}
+
+	cleaned := weekdayPrefix.ReplaceAllString(s, "")
+	cleaned = strings.ReplaceAll(cleaned, ",", "")
+	fields := strings.Fields(cleaned)
+	if len(fields) == 0 {
+		return time.Time{}, fmt.Errorf("unrecognised date format: %q", s)
+	}
+	if offset, ok := zoneOffsets[strings.ToUpper(fields[len(fields)-1])]; ok && len(fields) > 1 {
+		fields[len(fields)-1] = offset
+		cleaned = strings.Join(fields, " ")
+	}
+
+	for _, layout := range dateLayouts {
+		t, err := time.Parse(layout, cleaned)
+		if err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognised date format: %q", s)
+}
+
+// PublishedAt returns the item's publication date, or fetchedAt when the
+// date is missing or unparseable. The bool reports whether the fallback was
+// used. Either way the result is in UTC.
+func (item RSSItem) PublishedAt(fetchedAt time.Time) (time.Time, bool) {
+	t, err := ParseDate(item.PubDate)
+	if err != nil {
+		return fetchedAt.UTC(), true
+	}
+	return t, false
+}
@@ -0,0 +1,107 @@
+package rss
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name: "rfc1123 gmt",
+			in:   "Mon, 02 Jan 2006 15:04:05 GMT",
+			want: time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+		},
+		{
+			name: "rfc822 est",
+			in:   "Mon, 02 Jan 2006 10:04:05 EST",
+			want: time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+		},
+		{
+			name: "numeric offset",
+			in:   "Mon, 02 Jan 2006 17:04:05 +0200",
+			want: time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+		},
+		{
+			name: "two digit year",
+			in:   "02 Jan 06 15:04:05 GMT",
+			want: time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+		},
+		{
+			name: "rfc3339 with fractional seconds",
+			in:   "2006-01-02T15:04:05.123456Z",
+			want: time.Date(2006, 1, 2, 15, 4, 5, 123456000, time.UTC),
+		},
+		{
+			name: "rfc3339 with offset",
+			in:   "2006-01-02T16:04:05+01:00",
+			want: time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+		},
+		{
+			name: "extra whitespace",
+			in:   "  Mon,  02 Jan 2006\n15:04:05 GMT ",
+			want: time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+		},
+		{
+			name:    "empty",
+			in:      "",
+			wantErr: true,
+		},
+		{
+			name:    "whitespace only",
+			in:      " \t ",
+			wantErr: true,
+		},
+		{
+			name:    "weekday only",
+			in:      "Mon, ",
+			wantErr: true,
+		},
+		{
+			name:    "zone only",
+			in:      "GMT",
+			wantErr: true,
+		},
+		{
+			name:    "garbage",
+			in:      "yesterday",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDate(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseDate(%q) = %v, want error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDate(%q) returned error: %v", tt.in, err)
+			}
+			// Compare the wall clock too: the database drops the zone.
+			if got != tt.want {
+				t.Errorf("ParseDate(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublishedAtFallback(t *testing.T) {
+	fetchedAt := time.Date(2024, 1, 2, 10, 4, 5, 0, time.FixedZone("EST", -5*60*60))
+
+	got, estimated := RSSItem{PubDate: "soon"}.PublishedAt(fetchedAt)
+	if !estimated {
+		t.Errorf("PublishedAt reported a parsed date for %q", "soon")
+	}
+	if want := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC); got != want {
+		t.Errorf("PublishedAt = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,192 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// FeedLink is a feed found on a web page.
+type FeedLink struct {
+	URL   string
+	Title string
+	Type  string
+	// Feed is the downloaded feed. It is only set when discovery found a
+	// single feed.
+	Feed *RSSFeed
+}
+
+var feedTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": true,
+	"application/rdf+xml":   true,
+}
+
+// commonFeedPaths are tried when a page does not advertise its feeds.
+var commonFeedPaths = []string{
+	"/feed",
+	"/rss",
+	"/rss.xml",
+	"/atom.xml",
+	"/feed.xml",
+	"/index.xml",
+	"/feed.json",
+}
+
+// DiscoverFeeds returns the feeds available at pageURL. If pageURL is itself
+// a feed it is the only result. Otherwise the page's
+// <link rel="alternate"> elements are used, falling back to probing a few
+// common feed paths on the same site. When there is a single result it is
+// returned already downloaded, so callers need not fetch it again.
+func DiscoverFeeds(ctx context.Context, pageURL string) ([]FeedLink, error) {
+	body, contentType, final, err := fetch(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if rf, err := decodeFeed(body, contentType, final.String()); err == nil {
+		return []FeedLink{{URL: final.String(), Feed: rf}}, nil
+	}
+
+	links := feedLinks(body, final)
+	if len(links) > 1 {
+		return links, nil
+	}
+	if len(links) == 1 {
+		link := links[0]
+		body, contentType, feedURL, err := fetch(ctx, link.URL)
+		if err != nil {
+			return nil, err
+		}
+		link.URL = feedURL.String()
+		link.Feed, err = decodeFeed(body, contentType, link.URL)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid feed: %w", link.URL, err)
+		}
+		return []FeedLink{link}, nil
+	}
+
+	for _, path := range commonFeedPaths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		candidate := final.ResolveReference(&url.URL{Path: path})
+		body, contentType, feedURL, err := fetch(ctx, candidate.String())
+		if err != nil {
+			continue
+		}
+		if rf, err := decodeFeed(body, contentType, feedURL.String()); err == nil {
+			return []FeedLink{{URL: feedURL.String(), Feed: rf}}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no feeds found at %s", pageURL)
+}
+
+// feedLinks extracts the feeds advertised in an HTML page's <link> elements,
+// resolving their URLs against the page.
+func feedLinks(page []byte, pageURL *url.URL) []FeedLink {
+	z := html.NewTokenizer(bytes.NewReader(page))
+
+	// The first <base> applies to the whole page, including links before it.
+	var base *url.URL
+	var candidates []FeedLink
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+
+		t := z.Token()
+		attrs := attributes(t)
+		switch t.Data {
+		case "base":
+			if _, ok := attrs["href"]; !ok || base != nil {
+				continue
+			}
+			if href, err := url.Parse(strings.TrimSpace(attrs["href"])); err == nil {
+				base = pageURL.ResolveReference(href)
+			}
+		case "link":
+			if !contains(strings.Fields(strings.ToLower(attrs["rel"])), "alternate") {
+				continue
+			}
+
+			mimeType := strings.ToLower(strings.TrimSpace(attrs["type"]))
+			if !feedTypes[mimeType] || strings.TrimSpace(attrs["href"]) == "" {
+				continue
+			}
+
+			candidates = append(candidates, FeedLink{
+				URL:   strings.TrimSpace(attrs["href"]),
+				Title: strings.TrimSpace(attrs["title"]),
+				Type:  mimeType,
+			})
+		}
+	}
+	if base == nil {
+		base = pageURL
+	}
+
+	var links []FeedLink
+	seen := map[string]bool{}
+	for _, link := range candidates {
+		href, err := url.Parse(link.URL)
+		if err != nil {
+			continue
+		}
+		link.URL = base.ResolveReference(href).String()
+		if seen[link.URL] {
+			continue
+		}
+		seen[link.URL] = true
+
+		links = append(links, link)
+	}
+	return links
+}
+
+func attributes(t html.Token) map[string]string {
+	attrs := map[string]string{}
+	for _, a := range t.Attr {
+		attrs[a.Key] = a.Val
+	}
+	return attrs
+}
+
+// fetch downloads rawURL and returns the body, its content type and the URL
+// it was finally served from after redirects.
+func fetch(ctx context.Context, rawURL string) ([]byte, string, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	req.Header.Add("User-Agent", "gator")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, "", nil, fmt.Errorf("unexpected status fetching %s: %s", rawURL, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return body, resp.Header.Get("Content-Type"), resp.Request.URL, nil
+}
@@ -0,0 +1,86 @@
+package rss
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestFeedLinks(t *testing.T) {
+	page, err := url.Parse("https://example.com/blog/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		in   string
+		want []FeedLink
+	}{
+		{
+			name: "rss and atom",
+			in: `<html><head>` +
+				`<link rel="alternate" type="application/rss+xml" title="RSS" href="/feed.xml">` +
+				`<link rel="alternate" type="application/atom+xml" href="atom.xml">` +
+				`<link rel="stylesheet" href="/style.css">` +
+				`</head></html>`,
+			want: []FeedLink{
+				{URL: "https://example.com/feed.xml", Title: "RSS", Type: "application/rss+xml"},
+				{URL: "https://example.com/blog/atom.xml", Type: "application/atom+xml"},
+			},
+		},
+		{
+			name: "attribute case and quoting",
+			in:   `<LINK REL='Alternate Home' TYPE="Application/RSS+XML" HREF=/feed?a=1&amp;b=2>`,
+			want: []FeedLink{
+				{URL: "https://example.com/feed?a=1&b=2", Type: "application/rss+xml"},
+			},
+		},
+		{
+			name: "greater-than in attribute",
+			in:   `<link rel="alternate" title="a > b" type="application/rss+xml" href="/feed.xml">`,
+			want: []FeedLink{
+				{URL: "https://example.com/feed.xml", Title: "a > b", Type: "application/rss+xml"},
+			},
+		},
+		{
+			name: "comments and scripts",
+			in: `<!-- <link rel="alternate" type="application/rss+xml" href="/old.xml"> -->` +
+				`<script>document.write('<link rel="alternate" type="application/rss+xml" href="/js.xml">')</script>` +
+				`<link rel="alternate" type="application/rss+xml" href="/feed.xml">`,
+			want: []FeedLink{
+				{URL: "https://example.com/feed.xml", Type: "application/rss+xml"},
+			},
+		},
+		{
+			name: "base after link",
+			in: `<link rel="alternate" type="application/rss+xml" href="feed.xml">` +
+				`<base href="https://cdn.example.com/site/">` +
+				`<base href="https://ignored.example/">`,
+			want: []FeedLink{
+				{URL: "https://cdn.example.com/site/feed.xml", Type: "application/rss+xml"},
+			},
+		},
+		{
+			name: "duplicates",
+			in: `<link rel="alternate" type="application/rss+xml" href="/feed.xml">` +
+				`<link rel="alternate" type="application/rss+xml" href="https://example.com/feed.xml">`,
+			want: []FeedLink{
+				{URL: "https://example.com/feed.xml", Type: "application/rss+xml"},
+			},
+		},
+		{
+			name: "no feeds",
+			in:   `<link rel="alternate" hreflang="de" href="/de/"><p>hello</p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := feedLinks([]byte(tt.in), page)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("feedLinks =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
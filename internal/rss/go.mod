@@ -1,3 +1,5 @@
 module github.com/aranaris/gator
 
 go 1.22.5
+
+require golang.org/x/net v0.35.0
@@ -0,0 +1,102 @@
+package rss
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFeedJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		contentType string
+		wantLink    string
+		want        []RSSItem
+		wantErr     bool
+	}{
+		{
+			name: "version 1.1",
+			in: `{"version": "https://jsonfeed.org/version/1.1", "title": "Blog",
+				"home_page_url": "https://example.com/",
+				"items": [{"id": "a", "url": "https://example.com/a", "title": "A",
+					"summary": "Short", "content_html": "<p>Long</p>",
+					"date_published": "2024-01-02T03:04:05Z"}]}`,
+			contentType: "application/feed+json",
+			wantLink:    "https://example.com/",
+			want: []RSSItem{{
+				Title:       "A",
+				Link:        "https://example.com/a",
+				Description: "Short",
+				Content:     "<p>Long</p>",
+				PubDate:     "Tue, 02 Jan 2024 03:04:05 +0000",
+				GUID:        "a",
+			}},
+		},
+		{
+			name: "numeric ids",
+			in: `{"version": "https://jsonfeed.org/version/1", "items": [
+				{"id": 1, "content_text": "one"},
+				{"id": 2.5, "content_html": "<b>two</b>"}]}`,
+			contentType: "application/json",
+			want: []RSSItem{
+				{Description: "one", GUID: "1"},
+				{Description: "<b>two</b>", Content: "<b>two</b>", GUID: "2.5"},
+			},
+		},
+		{
+			name: "modified when not published",
+			in: `{"version": "https://jsonfeed.org/version/1.1", "items": [
+				{"id": "a", "date_modified": "2024-01-02T03:04:05+01:00"}]}`,
+			want: []RSSItem{{
+				PubDate: "Tue, 02 Jan 2024 03:04:05 +0100",
+				GUID:    "a",
+			}},
+		},
+		{
+			name: "attachments",
+			in: `{"version": "https://jsonfeed.org/version/1.1", "items": [
+				{"id": "a", "attachments": [
+					{"url": "https://example.com/a.mp3", "mime_type": "audio/mpeg", "size_in_bytes": 123},
+					{"url": "https://example.com/a.ogg", "mime_type": "audio/ogg"}]}]}`,
+			want: []RSSItem{{
+				GUID: "a",
+				Enclosures: []Enclosure{
+					{URL: "https://example.com/a.mp3", Length: "123", Type: "audio/mpeg"},
+					{URL: "https://example.com/a.ogg", Type: "audio/ogg"},
+				},
+			}},
+		},
+		{
+			name:    "version mismatch",
+			in:      `{"version": "1.0", "items": [{"id": "a"}]}`,
+			wantErr: true,
+		},
+		{
+			name:        "plain json",
+			in:          `{"items": []}`,
+			contentType: "application/json",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rf, err := parseFeed([]byte(tt.in), tt.contentType)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseFeed succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFeed returned error: %v", err)
+			}
+			if rf.Channel.Link != tt.wantLink {
+				t.Errorf("Channel.Link = %q, want %q", rf.Channel.Link, tt.wantLink)
+			}
+			if !reflect.DeepEqual(rf.Channel.Item, tt.want) {
+				t.Errorf("items =\n%+v\nwant\n%+v", rf.Channel.Item, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package rss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Subscription is a feed listed in an OPML subscription list.
+type Subscription struct {
+	Title   string
+	FeedURL string
+	SiteURL string
+	// Folder is the path of the folders the feed was filed under, separated
+	// by "/", or empty for top-level feeds.
+	Folder string
+}
+
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    struct {
+		Title       string `xml:"title"`
+		DateCreated string `xml:"dateCreated,omitempty"`
+	} `xml:"head"`
+	Body struct {
+		Outlines []opmlOutline `xml:"outline"`
+	} `xml:"body"`
+}
+
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+	Category string        `xml:"category,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+func (o opmlOutline) name() string {
+	if title := strings.TrimSpace(o.Title); title != "" {
+		return title
+	}
+	return strings.TrimSpace(o.Text)
+}
+
+// ReadOPML reads the feeds from an OPML 1.0 or 2.0 subscription list,
+// flattening nested outlines into folder paths.
+func ReadOPML(r io.Reader) ([]Subscription, error) {
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+
+	doc := opmlDocument{}
+	err := d.Decode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("error reading opml: %w", err)
+	}
+
+	var subs []Subscription
+	var walk func(outlines []opmlOutline, folder string)
+	walk = func(outlines []opmlOutline, folder string) {
+		for _, o := range outlines {
+			if feedURL := strings.TrimSpace(o.XMLURL); feedURL != "" {
+				sub := Subscription{
+					Title:   o.name(),
+					FeedURL: feedURL,
+					SiteURL: strings.TrimSpace(o.HTMLURL),
+					Folder:  folder,
+				}
+				if sub.Folder == "" {
+					sub.Folder = categoryFolder(o.Category)
+				}
+				subs = append(subs, sub)
+			}
+
+			path := o.name()
+			if folder != "" && path != "" {
+				path = folder + "/" + path
+			} else if path == "" {
+				path = folder
+			}
+			walk(o.Outlines, path)
+		}
+	}
+	walk(doc.Body.Outlines, "")
+
+	return subs, nil
+}
+
+// categoryFolder turns the first entry of an OPML 2.0 category attribute,
+// a comma separated list of slash delimited paths, into a folder path.
+func categoryFolder(category string) string {
+	first, _, _ := strings.Cut(category, ",")
+	return strings.Trim(strings.TrimSpace(first), "/")
+}
+
+// WriteOPML writes subs as an OPML 2.0 subscription list titled title,
+// nesting each feed under outlines for the folders in its path.
+func WriteOPML(w io.Writer, title string, subs []Subscription) error {
+	doc := opmlDocument{Version: "2.0"}
+	doc.Head.Title = title
+	doc.Head.DateCreated = time.Now().Format(time.RFC1123Z)
+
+	sorted := make([]Subscription, len(subs))
+	copy(sorted, subs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return strings.ToLower(sorted[i].Title) < strings.ToLower(sorted[j].Title)
+	})
+
+	root := &opmlFolder{}
+	for _, sub := range sorted {
+		folder := root
+		for _, name := range strings.Split(sub.Folder, "/") {
+			if name = strings.TrimSpace(name); name != "" {
+				folder = folder.child(name)
+			}
+		}
+
+		text := sub.Title
+		if text == "" {
+			text = sub.FeedURL
+		}
+		folder.outlines = append(folder.outlines, opmlOutline{
+			Text:    text,
+			Title:   text,
+			Type:    "rss",
+			XMLURL:  sub.FeedURL,
+			HTMLURL: sub.SiteURL,
+		})
+	}
+	doc.Body.Outlines = root.toOutlines()
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(doc)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// opmlFolder collects the outlines filed under one folder while a
+// subscription list is being written.
+type opmlFolder struct {
+	name     string
+	outlines []opmlOutline
+	children []*opmlFolder
+}
+
+func (f *opmlFolder) child(name string) *opmlFolder {
+	for _, c := range f.children {
+		if c.name == name {
+			return c
+		}
+	}
+	c := &opmlFolder{name: name}
+	f.children = append(f.children, c)
+	return c
+}
+
+// toOutlines returns the folder's feeds followed by its subfolders, sorted
+// by name.
+func (f *opmlFolder) toOutlines() []opmlOutline {
+	sort.SliceStable(f.children, func(i, j int) bool {
+		return strings.ToLower(f.children[i].name) < strings.ToLower(f.children[j].name)
+	})
+
+	outlines := f.outlines
+	for _, c := range f.children {
+		outlines = append(outlines, opmlOutline{
+			Text:     c.name,
+			Title:    c.name,
+			Outlines: c.toOutlines(),
+		})
+	}
+	return outlines
+}
@@ -0,0 +1,45 @@
+package rss
+
+import "strings"
+
+type Enclosure struct {
+	URL    string `xml:"url,attr"`
+	Length string `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// MediaContent is a Media RSS media:content element, which many feeds use
+// instead of, or as well as, a plain enclosure.
+type MediaContent struct {
+	URL      string `xml:"url,attr"`
+	FileSize string `xml:"fileSize,attr"`
+	Type     string `xml:"type,attr"`
+	Medium   string `xml:"medium,attr"`
+}
+
+type ITunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+// mergeMediaContent adds media:content entries that are not already listed
+// as enclosures, so callers only need to look at Enclosures.
+func (item *RSSItem) mergeMediaContent() {
+	seen := make(map[string]bool)
+	for _, enc := range item.Enclosures {
+		seen[enc.URL] = true
+	}
+
+	for _, mc := range item.MediaContent {
+		url := strings.TrimSpace(mc.URL)
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+
+		item.Enclosures = append(item.Enclosures, Enclosure{
+			URL:    url,
+			Length: mc.FileSize,
+			Type:   mc.Type,
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package rss
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// rdfFeed is an RSS 1.0 document. Unlike RSS 2.0 its items are siblings
+// of the channel rather than children of it.
+type rdfFeed struct {
+	Channel struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		Description string `xml:"description"`
+	} `xml:"channel"`
+	Image Image     `xml:"image"`
+	Items []rdfItem `xml:"item"`
+}
+
+type rdfItem struct {
+	About       string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+}
+
+func parseRDF(data []byte) (*RSSFeed, error) {
+	df := rdfFeed{}
+	err := xml.Unmarshal(data, &df)
+	if err != nil {
+		return nil, err
+	}
+
+	rf := RSSFeed{}
+	rf.Channel.Title = df.Channel.Title
+	rf.Channel.Link = strings.TrimSpace(df.Channel.Link)
+	rf.Channel.Description = df.Channel.Description
+	rf.Channel.Images = []Image{df.Image}
+
+	for _, it := range df.Items {
+		link := strings.TrimSpace(it.Link)
+		guid := strings.TrimSpace(it.About)
+		if guid == "" {
+			guid = link
+		}
+
+		rf.Channel.Item = append(rf.Channel.Item, RSSItem{
+			Title:       it.Title,
+			Link:        link,
+			Description: it.Description,
+			Content:     it.Content,
+			PubDate:     normalizeDate(it.Date),
+			GUID:        guid,
+		})
+	}
+
+	return &rf, nil
+}
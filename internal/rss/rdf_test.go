@@ -0,0 +1,86 @@
+package rss
+
+import (
+	"reflect"
+	"testing"
+)
+
+const rdfHeader = `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"` +
+	` xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/"` +
+	` xmlns:content="http://purl.org/rss/1.0/modules/content/">`
+
+func TestParseFeedRDF(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		wantLink string
+		want     []RSSItem
+	}{
+		{
+			name: "items beside the channel",
+			in: rdfHeader +
+				`<channel rdf:about="https://example.com/"><title>Blog</title>` +
+				`<link>https://example.com/</link><items><rdf:Seq>` +
+				`<rdf:li rdf:resource="https://example.com/1"/><rdf:li rdf:resource="https://example.com/2"/>` +
+				`</rdf:Seq></items></channel>` +
+				`<item rdf:about="https://example.com/1"><title>One</title><link>https://example.com/1</link></item>` +
+				`<item rdf:about="https://example.com/2"><title>Two</title><link>https://example.com/2</link></item>` +
+				`</rdf:RDF>`,
+			wantLink: "https://example.com/",
+			want: []RSSItem{
+				{Title: "One", Link: "https://example.com/1", GUID: "https://example.com/1"},
+				{Title: "Two", Link: "https://example.com/2", GUID: "https://example.com/2"},
+			},
+		},
+		{
+			name: "dc:date and content",
+			in: rdfHeader + `<channel><title>Blog</title></channel>` +
+				`<item rdf:about="https://example.com/1"><link>https://example.com/1</link>` +
+				`<description>Short</description><content:encoded>&lt;p&gt;Long&lt;/p&gt;</content:encoded>` +
+				`<dc:date>2024-01-02T03:04:05+01:00</dc:date></item>` +
+				`</rdf:RDF>`,
+			want: []RSSItem{{
+				Link:        "https://example.com/1",
+				Description: "Short",
+				Content:     "<p>Long</p>",
+				PubDate:     "Tue, 02 Jan 2024 03:04:05 +0100",
+				GUID:        "https://example.com/1",
+			}},
+		},
+		{
+			name: "rdf:about differs from link",
+			in: rdfHeader + `<channel><title>Blog</title></channel>` +
+				`<item rdf:about="urn:example:1"><link> https://example.com/1?utm_source=rss </link></item>` +
+				`</rdf:RDF>`,
+			want: []RSSItem{{
+				Link: "https://example.com/1?utm_source=rss",
+				GUID: "urn:example:1",
+			}},
+		},
+		{
+			name: "no rdf:about",
+			in: rdfHeader + `<channel><title>Blog</title></channel>` +
+				`<item><link>https://example.com/1</link></item>` +
+				`</rdf:RDF>`,
+			want: []RSSItem{{
+				Link: "https://example.com/1",
+				GUID: "https://example.com/1",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rf, err := parseFeed([]byte(tt.in), "application/rdf+xml")
+			if err != nil {
+				t.Fatalf("parseFeed returned error: %v", err)
+			}
+			if rf.Channel.Link != tt.wantLink {
+				t.Errorf("Channel.Link = %q, want %q", rf.Channel.Link, tt.wantLink)
+			}
+			if !reflect.DeepEqual(rf.Channel.Item, tt.want) {
+				t.Errorf("items =\n%+v\nwant\n%+v", rf.Channel.Item, tt.want)
+			}
+		})
+	}
+}
@@ -1,57 +1,187 @@
 package rss
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"html"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 )
 
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
+		Title       string        `xml:"title"`
+		Link        string        `xml:"-"`
+		Links       []ChannelLink `xml:"link"`
+		Description string        `xml:"description"`
+		Images      []Image       `xml:"image"`
+		Item        []RSSItem     `xml:"item"`
 	} `xml:"channel"`
+
+	// Cache validators from the response, to be sent back on the next fetch.
+	ETag         string `xml:"-"`
+	LastModified string `xml:"-"`
+}
+
+// ChannelLink is a link element in an RSS channel. Besides the site's own
+// <link>, channels often carry an <atom:link rel="self"> pointing at the feed.
+type ChannelLink struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+// siteLink returns the channel's plain <link>, ignoring namespaced ones.
+func (rf *RSSFeed) siteLink() string {
+	for _, link := range rf.Channel.Links {
+		if link.XMLName.Space == "" {
+			return link.Value
+		}
+	}
+	return ""
+}
+
+// Image is a channel's artwork, either an RSS image element or an
+// itunes:image.
+type Image struct {
+	URL  string `xml:"url"`
+	Href string `xml:"href,attr"`
+}
+
+// ImageURL returns the channel's artwork, if it has any.
+func (rf *RSSFeed) ImageURL() string {
+	for _, img := range rf.Channel.Images {
+		if url := strings.TrimSpace(img.URL); url != "" {
+			return url
+		}
+		if href := strings.TrimSpace(img.Href); href != "" {
+			return href
+		}
+	}
+	return ""
 }
 
 type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	Title       string      `xml:"title"`
+	Link        string      `xml:"link"`
+	Description string      `xml:"description"`
+	Content     string      `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate     string      `xml:"pubDate"`
+	GUID        string      `xml:"guid"`
+	Enclosures  []Enclosure `xml:"enclosure"`
+
+	MediaContent   []MediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	ITunesDuration string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	ITunesEpisode  string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+	ITunesSeason   string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd season"`
+	ITunesImage    ITunesImage    `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+
+	// hash is taken before the item is sanitized; see Hash.
+	hash string
+}
+
+// Key identifies the item within its feed: the guid when present, otherwise
+// the link, otherwise the title.
+func (item RSSItem) Key() string {
+	if guid := strings.TrimSpace(item.GUID); guid != "" {
+		return guid
+	}
+	if link := strings.TrimSpace(item.Link); link != "" {
+		return link
+	}
+	return strings.TrimSpace(item.Title)
+}
+
+// Hash fingerprints the parts of the item a publisher may edit after
+// publishing, so changed items can be detected on later fetches. Content is
+// only included when present so that hashes of items without it match
+// those recorded before it was captured. Items returned by FetchFeed are
+// hashed as published, before sanitizing, so that changes to the sanitizer
+// are not mistaken for edits.
+func (item RSSItem) Hash() string {
+	if item.hash != "" {
+		return item.hash
+	}
+
+	fields := []string{item.Title, item.Link, item.Description}
+	if item.Content != "" {
+		fields = append(fields, item.Content)
+	}
+
+	h := sha256.New()
+	for _, field := range fields {
+		io.WriteString(h, field)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
 }
 
+// ErrNotModified is returned by FetchFeedIfModified when the server reports
+// that the feed has not changed since the given validators were issued.
+var ErrNotModified = errors.New("feed not modified")
+
 func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+	return FetchFeedIfModified(ctx, feedURL, "", "")
+}
+
+// FetchFeedIfModified performs a conditional GET using the ETag and
+// Last-Modified values saved from a previous fetch. Empty values are not sent.
+func FetchFeedIfModified(ctx context.Context, feedURL, etag, lastModified string) (*RSSFeed, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
 	if err != nil {
-		fmt.Printf("Error building request: %s", err)
-		return nil, err
+		return nil, fmt.Errorf("building request: %w", err)
 	}
 
 	req.Header.Add("User-Agent", "gator")
+	if etag != "" {
+		req.Header.Add("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Add("If-Modified-Since", lastModified)
+	}
 
 	c := http.Client{}
 	resp, err := (&c).Do(req)
 	if err != nil {
-		fmt.Printf("Error getting response: %s", err)
-		return nil, err
+		return nil, fmt.Errorf("fetching feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, ErrNotModified
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status fetching %s: %s", feedURL, resp.Status)
 	}
 
 	bodyData, err := io.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Printf("Error processing response body: %s", err)
-		return nil, err
+		return nil, fmt.Errorf("reading feed: %w", err)
+	}
+
+	rf, err := decodeFeed(bodyData, resp.Header.Get("Content-Type"), feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing feed: %w", err)
 	}
 
-	rf := RSSFeed{}
+	rf.ETag = resp.Header.Get("ETag")
+	rf.LastModified = resp.Header.Get("Last-Modified")
+
+	return rf, nil
+}
 
-	err = xml.Unmarshal(bodyData, &rf)
+// decodeFeed parses a feed downloaded from feedURL, unescapes its titles,
+// resolves its links against the feed and sanitizes its HTML.
+func decodeFeed(data []byte, contentType, feedURL string) (*RSSFeed, error) {
+	rf, err := parseFeed(data, contentType)
 	if err != nil {
-		fmt.Printf("Error unmarshalling xml: %s", err)
 		return nil, err
 	}
 
@@ -60,7 +190,99 @@ func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	for i := range rf.Channel.Item {
 		rf.Channel.Item[i].Title = html.UnescapeString(rf.Channel.Item[i].Title)
 		rf.Channel.Item[i].Description = html.UnescapeString(rf.Channel.Item[i].Description) 
+		rf.Channel.Item[i].Content = html.UnescapeString(rf.Channel.Item[i].Content)
+	}
+
+	channelBase, _ := url.Parse(feedURL)
+	if link := safeURL(strings.TrimSpace(rf.Channel.Link), channelBase); link != "" {
+		rf.Channel.Link = link
+		channelBase, _ = url.Parse(link)
+	}
+	for i := range rf.Channel.Images {
+		img := &rf.Channel.Images[i]
+		img.URL = safeURL(strings.TrimSpace(img.URL), channelBase)
+		img.Href = safeURL(strings.TrimSpace(img.Href), channelBase)
+	}
+	rf.Channel.Description = Sanitize(rf.Channel.Description, channelBase)
+	for i := range rf.Channel.Item {
+		item := &rf.Channel.Item[i]
+		item.hash = item.Hash()
+
+		base := channelBase
+		if link, err := url.Parse(strings.TrimSpace(item.Link)); err == nil && base != nil {
+			base = base.ResolveReference(link)
+		}
+		item.Description = Sanitize(item.Description, base)
+		item.Content = Sanitize(item.Content, base)
+	}
+
+	return rf, nil
+}
+
+// parseFeed picks a decoder based on the content type or the document's
+// root element and normalizes the result into an RSSFeed.
+func parseFeed(data []byte, contentType string) (*RSSFeed, error) {
+	if isJSONFeed(data, contentType) {
+		return parseJSONFeed(data)
+	}
+
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch root.Local {
+	case "rss":
+		rf := RSSFeed{}
+		err = xml.Unmarshal(data, &rf)
+		if err != nil {
+			return nil, err
+		}
+		rf.Channel.Link = rf.siteLink()
+		for i := range rf.Channel.Item {
+			rf.Channel.Item[i].mergeMediaContent()
+		}
+		return &rf, nil
+	case "feed":
+		return parseAtom(data)
+	case "RDF":
+		return parseRDF(data)
+	default:
+		return nil, fmt.Errorf("unsupported feed format: <%s>", root.Local)
+	}
+}
+
+func rootElement(data []byte) (xml.Name, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return xml.Name{}, fmt.Errorf("no root element found: %w", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se.Name, nil
+		}
+	}
+}
+
+// w3cDateLayouts covers the W3C-DTF profile of ISO 8601 used by Atom,
+// JSON Feed and Dublin Core dates.
+var w3cDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+}
+
+// normalizeDate converts a W3C-DTF timestamp to the RFC 1123 form used by
+// RSS pubDate so callers can treat every item the same way.
+func normalizeDate(s string) string {
+	s = strings.TrimSpace(s)
+	for _, layout := range w3cDateLayouts {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t.Format(time.RFC1123Z)
+		}
 	}
-	
-	return &rf, nil
+	return s
 }
@@ -0,0 +1,50 @@
+package rss
+
+import "testing"
+
+func TestParseFeedChannelLink(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "plain link",
+			in:   `<rss><channel><link>https://example.com/</link></channel></rss>`,
+			want: "https://example.com/",
+		},
+		{
+			name: "atom self link first",
+			in: `<rss xmlns:atom="http://www.w3.org/2005/Atom"><channel>` +
+				`<atom:link href="https://example.com/feed.xml" rel="self" type="application/rss+xml"/>` +
+				`<link>https://example.com/</link></channel></rss>`,
+			want: "https://example.com/",
+		},
+		{
+			name: "atom self link last",
+			in: `<rss xmlns:atom="http://www.w3.org/2005/Atom"><channel>` +
+				`<link>https://example.com/</link>` +
+				`<atom:link href="https://example.com/feed.xml" rel="self" type="application/rss+xml"/>` +
+				`</channel></rss>`,
+			want: "https://example.com/",
+		},
+		{
+			name: "only atom link",
+			in: `<rss xmlns:atom="http://www.w3.org/2005/Atom"><channel>` +
+				`<atom:link href="https://example.com/feed.xml" rel="self"/></channel></rss>`,
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rf, err := parseFeed([]byte(tt.in), "application/rss+xml")
+			if err != nil {
+				t.Fatalf("parseFeed returned error: %v", err)
+			}
+			if rf.Channel.Link != tt.want {
+				t.Errorf("Channel.Link = %q, want %q", rf.Channel.Link, tt.want)
+			}
+		})
+	}
+}
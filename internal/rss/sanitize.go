@@ -0,0 +1,271 @@
+package rss
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// droppedElements are removed together with everything inside them.
+var droppedElements = map[string]bool{
+	"embed":    true,
+	"form":     true,
+	"iframe":   true,
+	"math":     true,
+	"noscript": true,
+	"object":   true,
+	"script":   true,
+	"style":    true,
+	"svg":      true,
+	"template": true,
+}
+
+// allowedElements maps each permitted element to its permitted attributes.
+// Anything else is removed, keeping its text.
+var allowedElements = map[string][]string{
+	"a":          {"href", "title"},
+	"abbr":       {"title"},
+	"b":          nil,
+	"blockquote": {"cite"},
+	"br":         nil,
+	"caption":    nil,
+	"cite":       nil,
+	"code":       nil,
+	"dd":         nil,
+	"del":        nil,
+	"div":        nil,
+	"dl":         nil,
+	"dt":         nil,
+	"em":         nil,
+	"figcaption": nil,
+	"figure":     nil,
+	"h1":         nil,
+	"h2":         nil,
+	"h3":         nil,
+	"h4":         nil,
+	"h5":         nil,
+	"h6":         nil,
+	"hr":         nil,
+	"i":          nil,
+	"img":        {"src", "alt", "title", "width", "height"},
+	"ins":        nil,
+	"kbd":        nil,
+	"li":         nil,
+	"mark":       nil,
+	"ol":         {"start"},
+	"p":          nil,
+	"pre":        nil,
+	"q":          {"cite"},
+	"s":          nil,
+	"small":      nil,
+	"span":       nil,
+	"strong":     nil,
+	"sub":        nil,
+	"sup":        nil,
+	"table":      nil,
+	"tbody":      nil,
+	"td":         {"colspan", "rowspan"},
+	"tfoot":      nil,
+	"th":         {"colspan", "rowspan"},
+	"thead":      nil,
+	"time":       {"datetime"},
+	"tr":         nil,
+	"u":          nil,
+	"ul":         nil,
+}
+
+var voidElements = map[string]bool{
+	"br":  true,
+	"hr":  true,
+	"img": true,
+}
+
+// impliedEnds lists elements whose end tag HTML lets publishers omit: a new
+// element of the same kind closes the previous one.
+var impliedEnds = map[string]bool{
+	"dd": true,
+	"dt": true,
+	"li": true,
+	"p":  true,
+	"td": true,
+	"th": true,
+	"tr": true,
+}
+
+var urlAttributes = map[string]bool{
+	"href": true,
+	"src":  true,
+	"cite": true,
+}
+
+// trackerHosts serve invisible images used to count views.
+var trackerHosts = []string{
+	"feeds.feedburner.com",
+	"pixel.wp.com",
+	"stats.wordpress.com",
+	"www.google-analytics.com",
+	"pixel.quantserve.com",
+	"feedpress.me",
+}
+
+// Sanitize removes everything from an HTML fragment that is not on the
+// allowlist: scripts, styles, iframes, event handlers, inline styles and
+// tracking pixels. Relative links and image sources are resolved against
+// base, which may be nil.
+func Sanitize(src string, base *url.URL) string {
+	z := html.NewTokenizer(strings.NewReader(src))
+
+	var b strings.Builder
+	var open []string
+	// While skip is set, everything up to its matching end tag is dropped.
+	var skip string
+	var depth int
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		t := z.Token()
+		name := t.Data
+
+		if skip != "" {
+			switch {
+			case tt == html.StartTagToken && name == skip:
+				depth++
+			case tt == html.EndTagToken && name == skip:
+				depth--
+				if depth == 0 {
+					skip = ""
+				}
+			}
+			continue
+		}
+
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedElements[name] {
+				if tt == html.StartTagToken && name != "embed" {
+					skip, depth = name, 1
+				}
+				continue
+			}
+			attrs, ok := allowedElements[name]
+			if !ok || (name == "img" && isTrackingPixel(t, base)) {
+				continue
+			}
+
+			if impliedEnds[name] && len(open) > 0 && open[len(open)-1] == name {
+				b.WriteString("</" + name + ">")
+				open = open[:len(open)-1]
+			}
+
+			b.WriteString("<" + name)
+			for _, a := range t.Attr {
+				if a.Namespace != "" || !contains(attrs, a.Key) {
+					continue
+				}
+
+				value := strings.TrimSpace(a.Val)
+				if urlAttributes[a.Key] {
+					value = safeURL(value, base)
+					if value == "" {
+						continue
+					}
+				}
+				b.WriteString(" " + a.Key + `="` + html.EscapeString(value) + `"`)
+			}
+			b.WriteString(">")
+
+			if !voidElements[name] {
+				open = append(open, name)
+			}
+		case html.EndTagToken:
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == name {
+					for j := len(open) - 1; j >= i; j-- {
+						b.WriteString("</" + open[j] + ">")
+					}
+					open = open[:i]
+					break
+				}
+			}
+		case html.TextToken:
+			b.WriteString(html.EscapeString(t.Data))
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+
+	return b.String()
+}
+
+// safeURL resolves ref against base and returns it only if it uses a scheme
+// that is safe to follow from a reader.
+func safeURL(ref string, base *url.URL) string {
+	u, err := url.Parse(ref)
+	if err != nil || ref == "" {
+		return ""
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+		return u.String()
+	case "":
+		// Still relative because there was no base to resolve against.
+		if strings.HasPrefix(ref, "#") || !strings.Contains(ref, ":") {
+			return u.String()
+		}
+	}
+	return ""
+}
+
+func isTrackingPixel(t html.Token, base *url.URL) bool {
+	var src, width, height string
+	for _, a := range t.Attr {
+		switch a.Key {
+		case "src":
+			src = a.Val
+		case "width":
+			width = a.Val
+		case "height":
+			height = a.Val
+		}
+	}
+
+	if tiny(width) && tiny(height) {
+		return true
+	}
+
+	u, err := url.Parse(safeURL(src, base))
+	if err != nil || u.Host == "" {
+		return src == ""
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, tracker := range trackerHosts {
+		if host == tracker || strings.HasSuffix(host, "."+tracker) {
+			return true
+		}
+	}
+	return false
+}
+
+func tiny(dimension string) bool {
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(dimension), "px"))
+	return err == nil && n <= 1
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package rss
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	base, err := url.Parse("https://example.com/blog/post.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		in   string
+		base *url.URL
+		want string
+	}{
+		{
+			name: "allowed markup",
+			in:   `<p>Hello <strong>world</strong></p>`,
+			want: `<p>Hello <strong>world</strong></p>`,
+		},
+		{
+			name: "javascript href",
+			in:   `<a href="javascript:alert(1)">x</a>`,
+			want: `<a>x</a>`,
+		},
+		{
+			name: "obfuscated javascript href",
+			in:   `<a href=" JaVaScRiPt:alert(1)">x</a>`,
+			want: `<a>x</a>`,
+		},
+		{
+			name: "data image source",
+			in:   `<img src="data:image/png;base64,AAAA" alt="a">`,
+			want: `<img alt="a">`,
+		},
+		{
+			name: "event handlers",
+			in:   `<p onclick="alert(1)" style="color:red">x<img src="https://example.com/a.png" onerror="alert(1)"></p>`,
+			want: `<p>x<img src="https://example.com/a.png"></p>`,
+		},
+		{
+			name: "unknown element keeps text",
+			in:   `<font color="red">x</font>`,
+			want: `x`,
+		},
+		{
+			name: "one pixel image",
+			in:   `<p>x<img src="https://example.com/t.gif" width="1" height="1"></p>`,
+			want: `<p>x</p>`,
+		},
+		{
+			name: "tracker host",
+			in:   `<img src="https://feeds.feedburner.com/~r/blog/~4/abc">`,
+			want: ``,
+		},
+		{
+			name: "image without source",
+			in:   `<img alt="x">`,
+			want: ``,
+		},
+		{
+			name: "relative urls",
+			in:   `<a href="../about">a</a><img src="/img/a.png"><a href="#top">t</a>`,
+			base: base,
+			want: `<a href="https://example.com/about">a</a><img src="https://example.com/img/a.png"><a href="https://example.com/blog/post.html#top">t</a>`,
+		},
+		{
+			name: "relative urls without base",
+			in:   `<a href="/about">a</a>`,
+			want: `<a href="/about">a</a>`,
+		},
+		{
+			name: "script",
+			in:   `<p>a</p><script>alert("<p>x</p>")</script><p>b</p>`,
+			want: `<p>a</p><p>b</p>`,
+		},
+		{
+			name: "unclosed script",
+			in:   `<p>a</p><script>alert(1)`,
+			want: `<p>a</p>`,
+		},
+		{
+			name: "nested svg",
+			in:   `<svg><svg><script>alert(1)</script></svg><text>x</text></svg><p>b</p>`,
+			want: `<p>b</p>`,
+		},
+		{
+			name: "unclosed svg",
+			in:   `<p>a</p><svg onload="alert(1)"><text>x</text>`,
+			want: `<p>a</p>`,
+		},
+		{
+			name: "comments",
+			in:   `a<!-- <script>alert(1)</script> -->b`,
+			want: `ab`,
+		},
+		{
+			name: "bare less-than",
+			in:   `<p>a < b</p><p>c</p>`,
+			want: `<p>a &lt; b</p><p>c</p>`,
+		},
+		{
+			name: "unclosed elements",
+			in:   `<ul><li>a<li>b</ul><p><em>c`,
+			want: `<ul><li>a</li><li>b</li></ul><p><em>c</em></p>`,
+		},
+		{
+			name: "escaped attributes",
+			in:   `<a href="https://example.com/?a=1&amp;b=2" title="&quot;x&quot;">x</a>`,
+			want: `<a href="https://example.com/?a=1&amp;b=2" title="&#34;x&#34;">x</a>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sanitize(tt.in, tt.base); got != tt.want {
+				t.Errorf("Sanitize(%q) =\n%s\nwant\n%s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -3,24 +3,32 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
 	"internal/config"
 	"internal/rss"
+	"net/url"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/aranaris/gator/internal/database"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 
-	"github.com/lib/pq"
 	_ "github.com/lib/pq"
 )
 
 type state struct {
 	cfg *config.Config
 	db *database.Queries
+	// output is the --output format, or empty for human-readable text.
+	output string
 }
 
 type command struct {
@@ -28,18 +36,36 @@ type command struct {
 	arguments []string
 }
 
+// parseFlags parses cmd.arguments into fs, allowing flags to appear before,
+// between or after positional arguments. It returns the positional arguments.
+func (cmd command) parseFlags(fs *flag.FlagSet) ([]string, error) {
+	var positional []string
+	args := cmd.arguments
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
 type commands struct {
-	mapping map[string]func(*state, command) error
+	mapping map[string]func(context.Context, *state, command) error
 }
 
-func middlewareLoggedIn(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
-	f := func(s *state, cmd command) error {
-		user, err := s.db.GetUser(context.Background(), s.cfg.CurrentUser)
+func middlewareLoggedIn(handler func(ctx context.Context, s *state, cmd command, user database.User) error) func(context.Context, *state, command) error {
+	f := func(ctx context.Context, s *state, cmd command) error {
+		user, err := s.db.GetUser(ctx, s.cfg.CurrentUser)
 		if err != nil {
 			return err
 		}
 		
-		err = handler(s, cmd, user)
+		err = handler(ctx, s, cmd, user)
 		if err != nil {
 			return err
 		}
@@ -49,12 +75,12 @@ func middlewareLoggedIn(handler func(s *state, cmd command, user database.User)
 	return f
 }
 
-func (c *commands) register(name string, f func(*state, command) error){
+func (c *commands) register(name string, f func(context.Context, *state, command) error){
 	c.mapping[name] = f
 }
 
-func (c *commands) run(s *state, cmd command) error {
-	err := c.mapping[cmd.name](s, cmd)
+func (c *commands) run(ctx context.Context, s *state, cmd command) error {
+	err := c.mapping[cmd.name](ctx, s, cmd)
 	if err != nil {
 		return err
 	}
@@ -62,12 +88,12 @@ func (c *commands) run(s *state, cmd command) error {
 	return nil
 }
 
-func loginHandler(s *state, cmd command) error {
+func loginHandler(ctx context.Context, s *state, cmd command) error {
 	if len(cmd.arguments) == 0 {
 		return fmt.Errorf("username required")
 	}
 
-	_, err := s.db.GetUser(context.Background(),cmd.arguments[0])
+	_, err := s.db.GetUser(ctx,cmd.arguments[0])
 	if err != nil {
 		os.Exit(1)
 	}
@@ -82,12 +108,12 @@ func loginHandler(s *state, cmd command) error {
 	return nil
 }
 
-func registerHandler(s *state, cmd command) error {
+func registerHandler(ctx context.Context, s *state, cmd command) error {
 	if len(cmd.arguments) == 0 {
 		return fmt.Errorf("name required")
 	}
 
-	_, err := s.db.GetUser(context.Background(),cmd.arguments[0])
+	_, err := s.db.GetUser(ctx,cmd.arguments[0])
 	if err == nil {
 		os.Exit(1)
 	}
@@ -103,7 +129,7 @@ func registerHandler(s *state, cmd command) error {
 		Name: cmd.arguments[0],
 	}
 
-	user, err := s.db.CreateUser(context.Background(), newUserParams)
+	user, err := s.db.CreateUser(ctx, newUserParams)
 	if err != nil {
 		return err
 	}
@@ -118,12 +144,12 @@ func registerHandler(s *state, cmd command) error {
 	return nil
 }
 
-func resetHandler(s *state, cmd command) error {
+func resetHandler(ctx context.Context, s *state, cmd command) error {
 	if len(cmd.arguments) > 0 {
 		return fmt.Errorf("too many arguments")
 	}
 
-	userCount, err := s.db.DeleteAllUsers(context.Background())
+	userCount, err := s.db.DeleteAllUsers(ctx)
 	if err != nil {
 		os.Exit(1)
 	}
@@ -133,17 +159,25 @@ func resetHandler(s *state, cmd command) error {
 	return nil
 }
 
-func usersHandler(s *state, cmd command) error {
+func usersHandler(ctx context.Context, s *state, cmd command) error {
 	if len(cmd.arguments) > 0 {
 		return fmt.Errorf("too many arguments")
 	}
 
-	users, err := s.db.GetUsers(context.Background())
+	users, err := s.db.GetUsers(ctx)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 
+	if s.output != "" {
+		records := make([]userRecord, len(users))
+		for i := range users {
+			records[i] = newUserRecord(users[i], users[i].Name == s.cfg.CurrentUser)
+		}
+		return writeRecords(os.Stdout, s.output, records)
+	}
+
 	for i := 0; i < len(users); i++ {
 		if users[i].Name == s.cfg.CurrentUser {
 			fmt.Printf("* %s (current)\n", users[i].Name)
@@ -156,99 +190,482 @@ func usersHandler(s *state, cmd command) error {
 	return nil
 }
 
-func aggHandler(s *state, cmd command) error {
-	if len(cmd.arguments) != 1 {
+type aggOptions struct {
+	concurrency int
+	backoff time.Duration
+	maxFailures int
+	timeout time.Duration
+}
+
+func aggHandler(ctx context.Context, s *state, cmd command) error {
+	fs := flag.NewFlagSet("agg", flag.ContinueOnError)
+	concurrency := fs.Int("concurrency", 1, "number of feeds to fetch in parallel per tick")
+	maxFailures := fs.Int("max-failures", 10, "disable a feed after this many consecutive failures (0 never disables)")
+	timeout := fs.Duration("timeout", 30*time.Second, "give up on a feed that takes longer than this to fetch and save")
+
+	args, err := cmd.parseFlags(fs)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
 		return fmt.Errorf("incorrect number of arguments (expected 1)")
 	}
+	if *concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1")
+	}
+	if *maxFailures < 0 {
+		return fmt.Errorf("max-failures cannot be negative")
+	}
+	if *timeout <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
 
-	timeBetweenReqs, err := time.ParseDuration(cmd.arguments[0])
+	timeBetweenReqs, err := time.ParseDuration(args[0])
 	if err != nil {
 		return err
 	}
 
+	opts := aggOptions{
+		concurrency: *concurrency,
+		backoff: timeBetweenReqs,
+		maxFailures: *maxFailures,
+		timeout: *timeout,
+	}
+
 	ticker := time.NewTicker(timeBetweenReqs)
-	for ; ; <- ticker.C {
-		err = scrapeFeeds(s)
-		if err != nil {
-			return err
+	defer ticker.Stop()
+
+	for {
+		err = scrapeFeeds(ctx, s, opts)
+		if err != nil && ctx.Err() == nil {
+			fmt.Printf("Error claiming feeds to fetch: %s\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Println("Aggregator stopped.")
+			return nil
+		case <-ticker.C:
 		}
 	}
 }
 
-func scrapeFeeds(s *state) error {
-	feed, err := s.db.GetNextFeedToFetch(context.Background())
+// scrapeFeeds claims up to opts.concurrency feeds that are due for a fetch
+// and scrapes them in parallel, giving each opts.timeout. Claimed feeds are
+// leased for longer than that, so several agg processes can share the same
+// database without fetching the same feed. Failures of individual feeds are
+// recorded on the feed rather than returned, and push its next fetch back
+// exponentially.
+func scrapeFeeds(ctx context.Context, s *state, opts aggOptions) error {
+	claimParams := database.ClaimFeedsToFetchParams{
+		// Leave time to record the result after the scrape has timed out.
+		LeaseSeconds: (2 * opts.timeout).Seconds(),
+		BackoffSeconds: opts.backoff.Seconds(),
+		MaxFeeds: int32(opts.concurrency),
+	}
+
+	feeds, err := s.db.ClaimFeedsToFetch(ctx, claimParams)
 	if err != nil {
 		return err
 	}
-	_, err = s.db.MarkFeedFetched(context.Background(), feed.ID)
+
+	var wg sync.WaitGroup
+	for i := range feeds {
+		wg.Add(1)
+		go func(feed database.Feed) {
+			defer wg.Done()
+			scrapeCtx, cancel := context.WithTimeout(ctx, opts.timeout)
+			defer cancel()
+			recordScrapeResult(ctx, s, opts, feed, scrapeFeed(scrapeCtx, s, feed))
+		}(feeds[i])
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func recordScrapeResult(ctx context.Context, s *state, opts aggOptions, feed database.Feed, scrapeErr error) {
+	if ctx.Err() != nil {
+		// Interrupted by shutdown, which says nothing about the feed itself.
+		return
+	}
+
+	if scrapeErr == nil {
+		err := s.db.RecordFeedSuccess(ctx, feed.ID)
+		if err != nil {
+			fmt.Printf("Error recording fetch of feed %s: %s\n", feed.Name, err)
+		}
+		return
+	}
+
+	failureParams := database.RecordFeedFailureParams{
+		ID: feed.ID,
+		LastError: sql.NullString{
+			String: scrapeErr.Error(),
+			Valid: true,
+		},
+	}
+
+	updated, err := s.db.RecordFeedFailure(ctx, failureParams)
 	if err != nil {
-		return err
+		fmt.Printf("Error recording failure of feed %s: %s\n", feed.Name, err)
+		return
 	}
 
-	rf, err := rss.FetchFeed(context.Background(), feed.Url)
+	fmt.Printf("Error scraping feed %s (%d consecutive failures): %s\n", feed.Name, updated.ConsecutiveFailures, scrapeErr)
+
+	if opts.maxFailures > 0 && int(updated.ConsecutiveFailures) >= opts.maxFailures {
+		_, err = s.db.DisableFeed(ctx, feed.ID)
+		if err != nil {
+			fmt.Printf("Error disabling feed %s: %s\n", feed.Name, err)
+			return
+		}
+		fmt.Printf("Feed %s has been disabled after %d consecutive failures. Use enablefeed to re-enable it.\n", feed.Name, updated.ConsecutiveFailures)
+	}
+}
+
+func scrapeFeed(ctx context.Context, s *state, feed database.Feed) error {
+	rf, err := rss.FetchFeedIfModified(ctx, feed.Url, feed.Etag.String, feed.LastModified.String)
+	if errors.Is(err, rss.ErrNotModified) {
+		fmt.Printf("Feed %s has not changed since last fetch.\n", feed.Name)
+		return nil
+	}
 	if err != nil {
-		fmt.Printf("Error fetching rss: %s", err)
 		return err
 	}
 
+	var postErrs []error
+	var newPosts, updatedPosts int
+	fetchedAt := time.Now()
 	newFeedItems := rf.Channel.Item
 	for i := range newFeedItems {
-		postID := uuid.New()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 
-		layout := "Mon, 02 Jan 2006 15:04:05 -0700"
-		parsedTime, err := time.Parse(layout, newFeedItems[i].PubDate)
+		result, err := savePost(ctx, s, feed, newFeedItems[i], fetchedAt)
 		if err != nil {
-			return err
+			postErrs = append(postErrs, fmt.Errorf("%s: %w", newFeedItems[i].Key(), err))
+			continue
+		}
+
+		switch result {
+		case postCreated:
+			newPosts++
+		case postUpdated:
+			updatedPosts++
+		}
+	}
+
+	if len(postErrs) > 0 {
+		return fmt.Errorf("%d of %d posts could not be saved: %w", len(postErrs), len(newFeedItems), errors.Join(postErrs...))
+	}
+
+	cacheParams := database.UpdateFeedCacheValidatorsParams{
+		ID: feed.ID,
+		Etag: sql.NullString{
+			String: rf.ETag,
+			Valid: rf.ETag != "",
+		},
+		LastModified: sql.NullString{
+			String: rf.LastModified,
+			Valid: rf.LastModified != "",
+		},
+	}
+
+	err = s.db.UpdateFeedCacheValidators(ctx, cacheParams)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d new and %d updated posts from feed %s saved.\n", newPosts, updatedPosts, feed.Name)
+
+	return nil
+}
+
+type postResult int
+
+const (
+	postUnchanged postResult = iota
+	postCreated
+	postUpdated
+)
+
+// savePost stores an item and its enclosures. The enclosures of a post that
+// has not changed are only written when they differ from the stored ones.
+func savePost(ctx context.Context, s *state, feed database.Feed, item rss.RSSItem, fetchedAt time.Time) (postResult, error) {
+	postID, result, err := upsertPost(ctx, s, feed, item, fetchedAt)
+	if err != nil || postID == 0 {
+		return result, err
+	}
+
+	if result == postUnchanged {
+		changed, err := enclosuresChanged(ctx, s, postID, item)
+		if err != nil || !changed {
+			return result, err
+		}
+	}
+
+	return result, saveEnclosures(ctx, s, postID, item)
+}
+
+// upsertPost inserts a new item, or updates the stored post when the item's
+// title, link, description or content have changed since it was last seen.
+// It returns the id of the stored post, or 0 if nothing was stored.
+func upsertPost(ctx context.Context, s *state, feed database.Feed, item rss.RSSItem, fetchedAt time.Time) (int64, postResult, error) {
+	key := item.Key()
+	if key == "" {
+		fmt.Printf("Skipping item without guid, link or title in feed %s\n", feed.Name)
+		return 0, postUnchanged, nil
+	}
+
+	hash := item.Hash()
+	description := sql.NullString{
+		String: item.Description,
+		Valid: true,
+	}
+	content := nullString(item.Content)
+
+	getParams := database.GetPostByFeedAndGuidParams{
+		FeedID: feed.ID,
+		Guid: key,
+	}
+
+	existing, err := s.db.GetPostByFeedAndGuid(ctx, getParams)
+	if errors.Is(err, sql.ErrNoRows) {
+		existing, err = adoptLegacyPost(ctx, s, feed, item.Link, key)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		postID := uuid.New()
+
+		publishedAt, estimated := item.PublishedAt(fetchedAt)
+		if estimated {
+			fmt.Printf("Could not parse date %q for %s, using fetch time\n", item.PubDate, item.Link)
 		}
 
 		postParams := database.CreatePostParams{
 			ID: int64(postID.ID()),
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
-			Title: newFeedItems[i].Title,
-			Url: newFeedItems[i].Link,
-			Description: sql.NullString{
-				String: newFeedItems[i].Description,
-				Valid: true,
-			},
-			PublishedAt: parsedTime,
+			Title: item.Title,
+			Url: item.Link,
+			Description: description,
+			PublishedAt: publishedAt,
 			FeedID: feed.ID,
+			PublishedAtEstimated: estimated,
+			Guid: key,
+			ContentHash: hash,
+			Content: content,
 		}
 
-		_, err = s.db.CreatePost(context.Background(), postParams)
-		if err, ok := err.(*pq.Error); ok {
-			if err.Message != "duplicate key value violates unique constraint \"posts_url_key\"" {
-				fmt.Println(err)
-				return err
-			}
+		inserted, err := s.db.CreatePost(ctx, postParams)
+		if err != nil || inserted == 0 {
+			return 0, postUnchanged, err
+		}
+		return postParams.ID, postCreated, nil
+	}
+	if err != nil {
+		return 0, postUnchanged, err
+	}
+
+	if existing.ContentHash == hash {
+		return existing.ID, postUnchanged, nil
+	}
+
+	// Posts saved before hashes or full content were recorded have nothing
+	// to compare against, so fill them in without recording a revision. If
+	// the item was edited since, it is saved as a revision below instead, so
+	// the new hash is never paired with the stale fields.
+	sameFields := existing.Title == item.Title && existing.Url == item.Link && sameDescription(existing, item)
+	if sameFields && (existing.ContentHash == "" || !existing.Content.Valid) {
+		backfillParams := database.BackfillPostContentParams{
+			ID: existing.ID,
+			Content: content,
+			ContentHash: hash,
+			Description: description,
 		}
+		return existing.ID, postUnchanged, s.db.BackfillPostContent(ctx, backfillParams)
+	}
+
+	revisionID := uuid.New()
+	updateParams := database.UpdatePostContentParams{
+		RevisionID: int64(revisionID.ID()),
+		RevisedAt: time.Now(),
+		PostID: existing.ID,
+		Title: item.Title,
+		Url: item.Link,
+		Description: description,
+		Content: content,
+		ContentHash: hash,
+	}
+
+	err = s.db.UpdatePostContent(ctx, updateParams)
+	if err != nil {
+		return 0, postUnchanged, err
+	}
+	return existing.ID, postUpdated, nil
+}
+
+// sameDescription compares the item's description with the stored one.
+// Posts saved before the sanitizer existed hold the description as it was
+// published, so it is sanitized the same way before comparing.
+func sameDescription(existing database.GetPostByFeedAndGuidRow, item rss.RSSItem) bool {
+	if existing.Description.String == item.Description {
+		return true
 	}
+	base, _ := url.Parse(strings.TrimSpace(item.Link))
+	return rss.Sanitize(existing.Description.String, base) == item.Description
+}
 
-	fmt.Printf("Posts from feed %s saved.\n", feed.Name)
+// adoptLegacyPost finds a post saved before guids were recorded, whose guid
+// was backfilled from its url, and gives it the item's real guid so it is
+// not saved a second time. It returns sql.ErrNoRows if there is none.
+func adoptLegacyPost(ctx context.Context, s *state, feed database.Feed, link, guid string) (database.GetPostByFeedAndGuidRow, error) {
+	if link == "" || link == guid {
+		return database.GetPostByFeedAndGuidRow{}, sql.ErrNoRows
+	}
+
+	legacyParams := database.GetLegacyPostByFeedAndURLParams{
+		FeedID: feed.ID,
+		Url: link,
+	}
+
+	legacyID, err := s.db.GetLegacyPostByFeedAndURL(ctx, legacyParams)
+	if err != nil {
+		return database.GetPostByFeedAndGuidRow{}, err
+	}
+
+	guidParams := database.UpdatePostGuidParams{
+		ID: legacyID,
+		Guid: guid,
+	}
+	err = s.db.UpdatePostGuid(ctx, guidParams)
+	if err != nil {
+		return database.GetPostByFeedAndGuidRow{}, err
+	}
+
+	getParams := database.GetPostByFeedAndGuidParams{
+		FeedID: feed.ID,
+		Guid: guid,
+	}
+	return s.db.GetPostByFeedAndGuid(ctx, getParams)
+}
+
+// saveEnclosures records the item's enclosures against a post.
+func saveEnclosures(ctx context.Context, s *state, postID int64, item rss.RSSItem) error {
+	for _, enc := range item.Enclosures {
+		encParams, ok := enclosureParams(postID, item, enc)
+		if !ok {
+			continue
+		}
+
+		err := s.db.UpsertPostEnclosure(ctx, encParams)
+		if err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
-func addFeedHandler(s *state, cmd command, user database.User) error {
-	if len(cmd.arguments) != 2 {
-		return fmt.Errorf("not enough arguments (expected 2)")
+// enclosuresChanged reports whether any of the item's enclosures is missing
+// from the post or differs from the stored copy.
+func enclosuresChanged(ctx context.Context, s *state, postID int64, item rss.RSSItem) (bool, error) {
+	stored, err := s.db.GetEnclosuresForPosts(ctx, []int64{postID})
+	if err != nil {
+		return false, err
 	}
-	
-	id := uuid.New()
 
-	feedParams := database.CreateFeedParams{
-		ID: int64(id.ID()),
+	byURL := make(map[string]database.PostEnclosure)
+	for _, enc := range stored {
+		byURL[enc.Url] = enc
+	}
+
+	for _, enc := range item.Enclosures {
+		p, ok := enclosureParams(postID, item, enc)
+		if !ok {
+			continue
+		}
+
+		old, ok := byURL[p.Url]
+		if !ok || old.Length != p.Length || old.MimeType != p.MimeType || old.Duration != p.Duration ||
+			old.Episode != p.Episode || old.Season != p.Season || old.ImageUrl != p.ImageUrl {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// enclosureParams builds the row for one of the item's enclosures. iTunes
+// episode details apply to the whole item and are copied onto each one. It
+// returns false for enclosures without a url.
+func enclosureParams(postID int64, item rss.RSSItem, enc rss.Enclosure) (database.UpsertPostEnclosureParams, bool) {
+	url := strings.TrimSpace(enc.URL)
+	if url == "" {
+		return database.UpsertPostEnclosureParams{}, false
+	}
+
+	encID := uuid.New()
+	return database.UpsertPostEnclosureParams{
+		ID: int64(encID.ID()),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
-		Name: cmd.arguments[0],
-		Url: cmd.arguments[1],
-		UserID: user.ID,
+		PostID: postID,
+		Url: url,
+		Length: nullInt64(enc.Length),
+		MimeType: nullString(enc.Type),
+		Duration: nullString(item.ITunesDuration),
+		Episode: nullInt32(item.ITunesEpisode),
+		Season: nullInt32(item.ITunesSeason),
+		ImageUrl: nullString(item.ITunesImage.Href),
+	}, true
+}
+
+func nullString(v string) sql.NullString {
+	v = strings.TrimSpace(v)
+	return sql.NullString{
+		String: v,
+		Valid: v != "",
+	}
+}
+
+func nullInt64(v string) sql.NullInt64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	return sql.NullInt64{
+		Int64: n,
+		Valid: err == nil,
+	}
+}
+
+func nullInt32(v string) sql.NullInt32 {
+	n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 32)
+	return sql.NullInt32{
+		Int32: int32(n),
+		Valid: err == nil,
 	}
+}
 
-	feed, err := s.db.CreateFeed(context.Background(), feedParams)
+func addFeedHandler(ctx context.Context, s *state, cmd command, user database.User) error {
+	var name, pageURL string
+	switch len(cmd.arguments) {
+	case 1:
+		pageURL = cmd.arguments[0]
+	case 2:
+		name, pageURL = cmd.arguments[0], cmd.arguments[1]
+	default:
+		return fmt.Errorf("incorrect number of arguments (expected [feed_name] <feed_url>)")
+	}
+
+	feed, created, err := createFeed(ctx, s, user, name, pageURL)
 	if err != nil {
 		return err
 	}
+	if !created {
+		fmt.Printf("Feed %s has already been added; following it.\n", feed.Url)
+	}
+
+	id := uuid.New()
 
 	followParams := database.CreateFeedFollowParams{
 		ID: int64(id.ID()),
@@ -258,7 +675,7 @@ func addFeedHandler(s *state, cmd command, user database.User) error {
 		FeedID: feed.ID,
 	}
 
-	_, err = s.db.CreateFeedFollow(context.Background(), followParams)
+	_, err = s.db.CreateFeedFollow(ctx, followParams)
 	if err != nil {
 		return err
 	}
@@ -268,37 +685,210 @@ func addFeedHandler(s *state, cmd command, user database.User) error {
 	return nil
 }
 
-func feedsHandler(s *state, cmd command) error {
-	if len(cmd.arguments) > 0 {
+// createFeed saves the feed found at pageURL, which may be a feed or a web
+// page that links to one, after checking that it can be fetched and parsed.
+// An empty name defaults to the feed's own title. If someone has already
+// added the feed it is returned as is; the bool reports whether it was
+// created.
+func createFeed(ctx context.Context, s *state, user database.User, name, pageURL string) (database.Feed, bool, error) {
+	link, err := discoverFeed(ctx, pageURL)
+	if err != nil {
+		return database.Feed{}, false, err
+	}
+	feedURL, rf := link.URL, link.Feed
+
+	feed, err := s.db.GetFeedByURL(ctx, feedURL)
+	if err == nil {
+		return feed, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.Feed{}, false, err
+	}
+
+	if name == "" {
+		name = strings.TrimSpace(rf.Channel.Title)
+	}
+	if name == "" {
+		return database.Feed{}, false, fmt.Errorf("feed %s has no title; give it a name", feedURL)
+	}
+	
+	id := uuid.New()
+
+	feedParams := database.CreateFeedParams{
+		ID: int64(id.ID()),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Name: name,
+		Url: feedURL,
+		UserID: user.ID,
+		Description: nullString(rf.Channel.Description),
+		SiteUrl: nullString(rf.Channel.Link),
+		ImageUrl: nullString(rf.ImageURL()),
+	}
+
+	feed, err = s.db.CreateFeed(ctx, feedParams)
+	if err != nil {
+		return database.Feed{}, false, err
+	}
+	return feed, true, nil
+}
+
+// discoverFeed returns the feed to subscribe to for a URL given by the
+// user, which may be a feed or a web page that links to one.
+func discoverFeed(ctx context.Context, pageURL string) (rss.FeedLink, error) {
+	links, err := rss.DiscoverFeeds(ctx, pageURL)
+	if err != nil {
+		return rss.FeedLink{}, err
+	}
+
+	if len(links) > 1 {
+		fmt.Printf("Found %d feeds at %s:\n", len(links), pageURL)
+		for _, link := range links {
+			if link.Title != "" {
+				fmt.Printf("- %s (%s)\n", link.URL, link.Title)
+			} else {
+				fmt.Printf("- %s\n", link.URL)
+			}
+		}
+		return rss.FeedLink{}, fmt.Errorf("multiple feeds found; run addfeed again with one of the URLs above")
+	}
+
+	if links[0].URL != pageURL {
+		fmt.Printf("Found feed %s\n", links[0].URL)
+	}
+	return links[0], nil
+}
+
+func feedsHandler(ctx context.Context, s *state, cmd command) error {
+	fs := flag.NewFlagSet("feeds", flag.ContinueOnError)
+	showErrors := fs.Bool("errors", false, "only show feeds whose last fetch failed")
+
+	args, err := cmd.parseFlags(fs)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
 		return fmt.Errorf("too many arguments")
 	}
 
-	feeds, err := s.db.GetFeeds(context.Background())
+	if *showErrors {
+		return feedErrorsHandler(ctx, s)
+	}
+
+	feeds, err := s.db.GetFeeds(ctx)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 
+	if s.output != "" {
+		return writeFeedRecords(s, feeds)
+	}
+
 	for i := 0; i < len(feeds); i++ {
-		user, err := s.db.GetUserByID(context.Background(), feeds[i].UserID)
+		user, err := s.db.GetUserByID(ctx, feeds[i].UserID)
 		if err != nil {
 			return err
 		}
 
-		fmt.Printf("* Name: %s || URL: %s || User: %s\n", feeds[i].Name, feeds[i].Url, user.Name)
+		status := ""
+		if feeds[i].DisabledAt.Valid {
+			status = " (disabled)"
+		}
+
+		fmt.Printf("* Name: %s || URL: %s || User: %s%s\n", feeds[i].Name, feeds[i].Url, user.Name, status)
+	}
+
+	return nil
+}
+
+func feedErrorsHandler(ctx context.Context, s *state) error {
+	feeds, err := s.db.GetFeedsWithErrors(ctx)
+	if err != nil {
+		return err
+	}
+
+	if s.output != "" {
+		return writeFeedRecords(s, feeds)
+	}
+
+	if len(feeds) == 0 {
+		fmt.Println("No feeds are currently failing.")
+		return nil
+	}
+
+	for i := range feeds {
+		lastSuccess := "never"
+		if feeds[i].LastSucceededAt.Valid {
+			lastSuccess = feeds[i].LastSucceededAt.Time.Format(time.DateTime)
+		}
+
+		status := ""
+		if feeds[i].DisabledAt.Valid {
+			status = " (disabled)"
+		}
+
+		fmt.Printf("* Name: %s || URL: %s || Failures: %d || Last success: %s%s\n", feeds[i].Name, feeds[i].Url, feeds[i].ConsecutiveFailures, lastSuccess, status)
+		fmt.Printf("  Error: %s\n", feeds[i].LastError.String)
+	}
+
+	return nil
+}
+
+func writeFeedRecords(s *state, feeds []database.Feed) error {
+	records := make([]feedRecord, len(feeds))
+	for i := range feeds {
+		records[i] = newFeedRecord(feeds[i])
+	}
+	return writeRecords(os.Stdout, s.output, records)
+}
+
+func enableFeedHandler(ctx context.Context, s *state, cmd command) error {
+	if len(cmd.arguments) != 1 {
+		return fmt.Errorf("incorrect number of arguments (expected 1)")
+	}
+
+	feed, err := s.db.GetFeedByURL(ctx, cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.EnableFeed(ctx, feed.ID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Feed %s has been enabled.\n", feed.Name)
+	return nil
+}
+
+func disableFeedHandler(ctx context.Context, s *state, cmd command) error {
+	if len(cmd.arguments) != 1 {
+		return fmt.Errorf("incorrect number of arguments (expected 1)")
+	}
+
+	feed, err := s.db.GetFeedByURL(ctx, cmd.arguments[0])
+	if err != nil {
+		return err
 	}
 
+	_, err = s.db.DisableFeed(ctx, feed.ID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Feed %s has been disabled.\n", feed.Name)
 	return nil
 }
 
-func followHandler(s *state, cmd command, user database.User) error {
+func followHandler(ctx context.Context, s *state, cmd command, user database.User) error {
 	if len(cmd.arguments) != 1 {
 		return fmt.Errorf("incorrect number of arguments (expected 1)")
 	}
 
 	id := uuid.New()
 
-	feed, err := s.db.GetFeedByURL(context.Background(), cmd.arguments[0])
+	feed, err := s.db.GetFeedByURL(ctx, cmd.arguments[0])
 	if err != nil {
 		return err
 	}
@@ -311,7 +901,7 @@ func followHandler(s *state, cmd command, user database.User) error {
 		FeedID: feed.ID,
 	}
 
-	newFeedFollow, err := s.db.CreateFeedFollow(context.Background(), followParams)
+	newFeedFollow, err := s.db.CreateFeedFollow(ctx, followParams)
 	if err != nil {
 		return err
 	}
@@ -321,31 +911,49 @@ func followHandler(s *state, cmd command, user database.User) error {
 	return nil
 }
 
-func followingHandler (s *state, cmd command, user database.User) error {
+func followingHandler(ctx context.Context, s *state, cmd command, user database.User) error {
 	if len(cmd.arguments) > 0 {
 		return fmt.Errorf("too many arguments")
 	}
 
-	feedFollows, err := s.db.GetFeedFollowsForUser(context.Background(), user.ID)
+	feedFollows, err := s.db.GetFeedFollowsForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	unreadCounts, err := s.db.GetUnreadCountsForUser(ctx, user.ID)
 	if err != nil {
 		return err
 	}
 
+	unreadByFeed := make(map[int64]int64)
+	for _, c := range unreadCounts {
+		unreadByFeed[c.FeedID] = c.Unread
+	}
+
+	if s.output != "" {
+		records := make([]feedFollowRecord, len(feedFollows))
+		for i := range feedFollows {
+			records[i] = newFeedFollowRecord(feedFollows[i], unreadByFeed[feedFollows[i].FeedID])
+		}
+		return writeRecords(os.Stdout, s.output, records)
+	}
+
 	fmt.Printf("User %s is following feeds: \n", s.cfg.CurrentUser)
 
 	for i := 0; i < len(feedFollows); i++ {
-		fmt.Printf("- %s\n", feedFollows[i].FeedName)
+		fmt.Printf("- %s (%d unread)\n", feedFollows[i].FeedName, unreadByFeed[feedFollows[i].FeedID])
 	}
 
 	return nil
 }
 
-func unFollowHandler (s *state, cmd command, user database.User) error {
+func unFollowHandler(ctx context.Context, s *state, cmd command, user database.User) error {
 	if len(cmd.arguments) != 1 {
 		return fmt.Errorf("incorrect number of arguments (expected 1)")
 	}
 
-	feed, err := s.db.GetFeedByURL(context.Background(), cmd.arguments[0])
+	feed, err := s.db.GetFeedByURL(ctx, cmd.arguments[0])
 	if err != nil {
 		return err
 	}
@@ -355,7 +963,7 @@ func unFollowHandler (s *state, cmd command, user database.User) error {
 		FeedID: feed.ID,
 	}
 
-	_ , err = s.db.DeleteFeedFollow(context.Background(), deleteParams)
+	_ , err = s.db.DeleteFeedFollow(ctx, deleteParams)
 	if err != nil {
 		return err
 	}
@@ -364,13 +972,31 @@ func unFollowHandler (s *state, cmd command, user database.User) error {
 	return nil
 }
 
-func browseHandler (s *state, cmd command, user database.User) error {
+func browseHandler(ctx context.Context, s *state, cmd command, user database.User) error {
+	fs := flag.NewFlagSet("browse", flag.ContinueOnError)
+	unread := fs.Bool("unread", false, "only show posts you have not read")
+	starred := fs.Bool("starred", false, "only show posts you have starred")
+	feedURL := fs.String("feed", "", "only show posts from the feed with this URL")
+	since := fs.String("since", "", "only posts published on or after this date (YYYY-MM-DD)")
+	until := fs.String("until", "", "only posts published on or before this date (YYYY-MM-DD)")
+	offset := fs.Int("offset", 0, "skip this many posts")
+	sortOrder := fs.String("sort", "newest", "sort order: newest or oldest")
+	all := fs.Bool("all", false, "show every matching post")
+
+	args, err := cmd.parseFlags(fs)
+	if err != nil {
+		return err
+	}
+
 	var limit int
 
-	if len(cmd.arguments) > 1 {
+	if len(args) > 1 {
 		return fmt.Errorf("too many arguments")
-	} else if len(cmd.arguments) == 1 {
-		converted, err := strconv.Atoi(cmd.arguments[0]) 
+	} else if len(args) == 1 {
+		if *all {
+			return fmt.Errorf("a limit cannot be combined with --all")
+		}
+		converted, err := strconv.Atoi(args[0]) 
 		if err != nil {
 			return err
 		}
@@ -379,24 +1005,337 @@ func browseHandler (s *state, cmd command, user database.User) error {
 		limit = 2
 	}
 
-	getParams := database.GetPostsForUserParams{
+	if *offset < 0 {
+		return fmt.Errorf("--offset must not be negative")
+	}
+	if *sortOrder != "newest" && *sortOrder != "oldest" {
+		return fmt.Errorf("unknown sort order %q (expected newest or oldest)", *sortOrder)
+	}
+
+	getParams := database.BrowsePostsForUserParams{
 		UserID: user.ID,
-		Limit: int32(limit),
+		Starred: *starred,
+		Unread: *unread,
+		FeedUrl: nullString(*feedURL),
+		OldestFirst: *sortOrder == "oldest",
+		PostLimit: sql.NullInt32{Int32: int32(limit), Valid: !*all},
+		PostOffset: int32(*offset),
+	}
+	getParams.Since, err = parseDateFlag("since", *since, false)
+	if err != nil {
+		return err
+	}
+	getParams.Until, err = parseDateFlag("until", *until, true)
+	if err != nil {
+		return err
+	}
+
+	posts, err := s.db.BrowsePostsForUser(ctx, getParams)
+	if err != nil {
+		return err
+	}
+
+	if s.output != "" {
+		records := make([]postRecord, len(posts))
+		for i := range posts {
+			records[i] = newPostRecord(posts[i])
+		}
+		return writeRecords(os.Stdout, s.output, records)
+	}
+
+	postIDs := make([]int64, len(posts))
+	for i := range posts {
+		postIDs[i] = posts[i].ID
 	}
 
-	posts, err := s.db.GetPostsForUser(context.Background(), getParams)
+	enclosures, err := s.db.GetEnclosuresForPosts(ctx, postIDs)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Showing last %d RSS posts for %s:\n", limit, user.Name)
+	enclosuresByPost := make(map[int64][]database.PostEnclosure)
+	for _, enc := range enclosures {
+		enclosuresByPost[enc.PostID] = append(enclosuresByPost[enc.PostID], enc)
+	}
+
+	kind := ""
+	if *unread {
+		kind += "unread "
+	}
+	if *starred {
+		kind += "starred "
+	}
+
+	if len(posts) == 0 {
+		fmt.Printf("No %sRSS posts found for %s.\n", kind, user.Name)
+		return nil
+	}
+
+	fmt.Printf("Showing %sRSS posts %d-%d for %s:\n", kind, *offset+1, *offset+len(posts), user.Name)
 
 	for i := range posts {
-		fmt.Printf("- %s: %s\n", posts[i].PublishedAt.Format("Jan 02 06"), posts[i].Title)
+		date := posts[i].PublishedAt.Format("Jan 02 06")
+		if posts[i].PublishedAtEstimated {
+			date = "~" + date
+		}
+
+		status := ""
+		if posts[i].RevisedAt.Valid {
+			status = " (updated)"
+		}
+
+		fmt.Printf("- [%d] %s: %s%s\n", posts[i].ID, date, posts[i].Title, status)
+
+		for _, enc := range enclosuresByPost[posts[i].ID] {
+			fmt.Printf("    %s\n", formatEnclosure(enc))
+		}
+	}
+
+	if !*all && len(posts) == limit {
+		fmt.Printf("Use --offset %d to see more.\n", *offset+len(posts))
 	}
 	return nil
 }
 
+func readHandler(ctx context.Context, s *state, cmd command, user database.User) error {
+	fs := flag.NewFlagSet("read", flag.ContinueOnError)
+	width := fs.Int("width", 80, "wrap text at this many columns")
+
+	args, err := cmd.parseFlags(fs)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("incorrect number of arguments (expected 1)")
+	}
+	if *width < 20 {
+		return fmt.Errorf("width must be at least 20")
+	}
+
+	post, err := getPostForUser(ctx, s, user, args[0])
+	if err != nil {
+		return err
+	}
+
+	body := sanitizeStored(post.Content, post.Url).String
+	if strings.TrimSpace(body) == "" {
+		body = sanitizeStored(post.Description, post.Url).String
+	}
+
+	styled := isTerminal(os.Stdout)
+	title := stripControl(post.Title)
+	if styled {
+		title = ansiBold + title + ansiReset
+	}
+
+	fmt.Println(title)
+	fmt.Printf("%s · %s\n\n", post.PublishedAt.Format("Jan 02 2006"), stripControl(post.Url))
+	fmt.Print(renderHTML(body, *width, styled))
+
+	readParams := database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+		ReadAt: time.Now(),
+	}
+	return s.db.MarkPostRead(ctx, readParams)
+}
+
+// getPostForUser looks up a post in the user's followed feeds by the id
+// shown in browse or by its URL.
+func getPostForUser(ctx context.Context, s *state, user database.User, ref string) (database.GetPostForUserRow, error) {
+	postID, _ := strconv.ParseInt(ref, 10, 64)
+	getParams := database.GetPostForUserParams{
+		UserID: user.ID,
+		PostID: postID,
+		Url: ref,
+	}
+
+	post, err := s.db.GetPostForUser(ctx, getParams)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.GetPostForUserRow{}, fmt.Errorf("no post %s in your followed feeds or starred posts", ref)
+	}
+	return post, err
+}
+
+// sanitizeStored runs a post's description or content through the sanitizer
+// before it is shown or exported. Posts saved before it existed were stored
+// as published.
+func sanitizeStored(v sql.NullString, link string) sql.NullString {
+	base, _ := url.Parse(link)
+	v.String = rss.Sanitize(v.String, base)
+	return v
+}
+
+func markReadHandler(ctx context.Context, s *state, cmd command, user database.User) error {
+	fs := flag.NewFlagSet("markread", flag.ContinueOnError)
+	feedURL := fs.String("feed", "", "mark every post in the feed with this URL as read")
+
+	args, err := cmd.parseFlags(fs)
+	if err != nil {
+		return err
+	}
+
+	if *feedURL != "" {
+		if len(args) > 0 {
+			return fmt.Errorf("too many arguments")
+		}
+
+		feed, err := s.db.GetFeedByURL(ctx, *feedURL)
+		if err != nil {
+			return err
+		}
+
+		feedParams := database.MarkFeedReadParams{
+			UserID: user.ID,
+			ReadAt: time.Now(),
+			FeedID: feed.ID,
+		}
+		n, err := s.db.MarkFeedRead(ctx, feedParams)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Marked %d posts in %s as read.\n", n, feed.Name)
+		return nil
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("incorrect number of arguments (expected 1)")
+	}
+
+	post, err := getPostForUser(ctx, s, user, args[0])
+	if err != nil {
+		return err
+	}
+
+	readParams := database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+		ReadAt: time.Now(),
+	}
+	err = s.db.MarkPostRead(ctx, readParams)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Marked %s as read.\n", post.Title)
+	return nil
+}
+
+func markUnreadHandler(ctx context.Context, s *state, cmd command, user database.User) error {
+	if len(cmd.arguments) != 1 {
+		return fmt.Errorf("incorrect number of arguments (expected 1)")
+	}
+
+	post, err := getPostForUser(ctx, s, user, cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+
+	unreadParams := database.MarkPostUnreadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	}
+	_, err = s.db.MarkPostUnread(ctx, unreadParams)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Marked %s as unread.\n", post.Title)
+	return nil
+}
+
+func starHandler(ctx context.Context, s *state, cmd command, user database.User) error {
+	if len(cmd.arguments) != 1 {
+		return fmt.Errorf("incorrect number of arguments (expected 1)")
+	}
+
+	post, err := getPostForUser(ctx, s, user, cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+
+	starParams := database.StarPostParams{
+		UserID: user.ID,
+		PostID: post.ID,
+		StarredAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}
+	err = s.db.StarPost(ctx, starParams)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Starred %s.\n", post.Title)
+	return nil
+}
+
+func unstarHandler(ctx context.Context, s *state, cmd command, user database.User) error {
+	if len(cmd.arguments) != 1 {
+		return fmt.Errorf("incorrect number of arguments (expected 1)")
+	}
+
+	post, err := getPostForUser(ctx, s, user, cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+
+	unstarParams := database.UnstarPostParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	}
+	n, err := s.db.UnstarPost(ctx, unstarParams)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%s is not starred", post.Title)
+	}
+
+	fmt.Printf("Unstarred %s.\n", post.Title)
+	return nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func formatEnclosure(enc database.PostEnclosure) string {
+	var details []string
+	if enc.MimeType.Valid {
+		details = append(details, enc.MimeType.String)
+	}
+	if enc.Length.Valid && enc.Length.Int64 > 0 {
+		details = append(details, fmt.Sprintf("%.1f MB", float64(enc.Length.Int64)/(1<<20)))
+	}
+	if enc.Duration.Valid {
+		details = append(details, formatDuration(enc.Duration.String))
+	}
+	if enc.Season.Valid && enc.Episode.Valid {
+		details = append(details, fmt.Sprintf("S%d E%d", enc.Season.Int32, enc.Episode.Int32))
+	} else if enc.Episode.Valid {
+		details = append(details, fmt.Sprintf("Episode %d", enc.Episode.Int32))
+	}
+
+	if len(details) == 0 {
+		return enc.Url
+	}
+	return fmt.Sprintf("%s (%s)", enc.Url, strings.Join(details, ", "))
+}
+
+// formatDuration renders an itunes:duration given in seconds as h:mm:ss.
+// Durations already in clock format are returned unchanged.
+func formatDuration(d string) string {
+	secs, err := strconv.Atoi(d)
+	if err != nil {
+		return d
+	}
+	if secs >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", secs/3600, secs%3600/60, secs%60)
+	}
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
+
 func main() {
 	err := godotenv.Load()
 	if err != nil {
@@ -415,13 +1354,21 @@ func main() {
 		fmt.Println(err)
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// Restore the default behaviour so a second signal exits immediately.
+		<-ctx.Done()
+		stop()
+	}()
+
 	s := state{
 		cfg: &cfg,
 		db: dbQueries,
 	}
 
 	cmds := commands{
-		mapping: make(map[string]func(*state, command) error),
+		mapping: make(map[string]func(context.Context, *state, command) error),
 	}
 
 	cmds.register("login", loginHandler)
@@ -431,27 +1378,49 @@ func main() {
 	cmds.register("agg", aggHandler)
 	cmds.register("addfeed", middlewareLoggedIn(addFeedHandler))
 	cmds.register("feeds", feedsHandler)
+	cmds.register("enablefeed", enableFeedHandler)
+	cmds.register("disablefeed", disableFeedHandler)
 	cmds.register("follow", middlewareLoggedIn(followHandler))
 	cmds.register("following", middlewareLoggedIn(followingHandler))
 	cmds.register("unfollow", middlewareLoggedIn(unFollowHandler))
 	cmds.register("browse", middlewareLoggedIn(browseHandler))
+	cmds.register("search", middlewareLoggedIn(searchHandler))
+	cmds.register("read", middlewareLoggedIn(readHandler))
+	cmds.register("markread", middlewareLoggedIn(markReadHandler))
+	cmds.register("markunread", middlewareLoggedIn(markUnreadHandler))
+	cmds.register("star", middlewareLoggedIn(starHandler))
+	cmds.register("unstar", middlewareLoggedIn(unstarHandler))
+	cmds.register("autodownload", middlewareLoggedIn(autoDownloadHandler))
+	cmds.register("download", middlewareLoggedIn(downloadHandler))
+	cmds.register("import", middlewareLoggedIn(importHandler))
+	cmds.register("export", middlewareLoggedIn(exportHandler))
+
+	output, args, err := extractOutputFlag(os.Args[1:])
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
 
-	args := os.Args
-
-	if len(args) < 2 {
+	if len(args) < 1 {
 		fmt.Println("Error: Not enough arguments")
 		os.Exit(1)
 	}
 
-	cmdName := args[1]
-	cmdArgs := args[2:]
+	cmdName := args[0]
+	cmdArgs := args[1:]
+
+	if output != "" && !outputCommands[cmdName] {
+		fmt.Printf("Error: --output is not supported by %s\n", cmdName)
+		os.Exit(1)
+	}
+	s.output = output
 
 	cmd := command{
 		name: cmdName,
 		arguments: cmdArgs,
 	}
 
-	err = cmds.run(&s, cmd)
+	err = cmds.run(ctx, &s, cmd)
 	if err != nil {
 		fmt.Printf("Error running command: %s\n", err)
 		os.Exit(1)
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"internal/rss"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aranaris/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func importHandler(ctx context.Context, s *state, cmd command, user database.User) error {
+	if len(cmd.arguments) != 1 {
+		return fmt.Errorf("incorrect number of arguments (expected 1)")
+	}
+
+	f, err := os.Open(cmd.arguments[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	subs, err := rss.ReadOPML(f)
+	if err != nil {
+		return err
+	}
+
+	feedFollows, err := s.db.GetFeedFollowsForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	following := make(map[int64]bool)
+	for _, ff := range feedFollows {
+		following[ff.FeedID] = true
+	}
+
+	var created, followed, skipped, failed int
+	for _, sub := range subs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		label := sub.FeedURL
+		if sub.Title != "" {
+			label = fmt.Sprintf("%s (%s)", sub.Title, sub.FeedURL)
+		}
+
+		feed, isNew, err := importFeed(ctx, s, user, sub)
+		if err != nil {
+			fmt.Printf("failed   %s: %v\n", label, err)
+			failed++
+			continue
+		}
+
+		if following[feed.ID] {
+			fmt.Printf("skipped  %s: already following\n", label)
+			skipped++
+			continue
+		}
+
+		followParams := database.CreateFeedFollowParams{
+			ID:        int64(uuid.New().ID()),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			UserID:    user.ID,
+			FeedID:    feed.ID,
+			Folder:    nullString(sub.Folder),
+		}
+		_, err = s.db.CreateFeedFollow(ctx, followParams)
+		if err != nil {
+			fmt.Printf("failed   %s: %v\n", label, err)
+			failed++
+			continue
+		}
+		following[feed.ID] = true
+
+		if isNew {
+			fmt.Printf("created  %s\n", label)
+			created++
+		} else {
+			fmt.Printf("followed %s\n", label)
+			followed++
+		}
+	}
+
+	fmt.Printf("Imported %d feeds: %d created, %d followed, %d skipped, %d failed.\n", len(subs), created, followed, skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d feeds could not be imported", failed)
+	}
+	return nil
+}
+
+// importFeed returns the saved feed for sub, creating it the same way
+// addfeed does if nobody has added it yet. The bool reports whether the
+// feed was created.
+func importFeed(ctx context.Context, s *state, user database.User, sub rss.Subscription) (database.Feed, bool, error) {
+	feed, err := s.db.GetFeedByURL(ctx, sub.FeedURL)
+	if err == nil {
+		return feed, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.Feed{}, false, err
+	}
+
+	// The listed URL may redirect to, or be a page linking to, a feed that
+	// is already saved under its final URL; createFeed checks for that.
+	return createFeed(ctx, s, user, sub.Title, sub.FeedURL)
+}
+
+func exportHandler(ctx context.Context, s *state, cmd command, user database.User) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	opml := fs.Bool("opml", false, "write followed feeds as an OPML 2.0 subscription list")
+
+	args, err := cmd.parseFlags(fs)
+	if err != nil {
+		return err
+	}
+	if !*opml {
+		return fmt.Errorf("no export format given (expected --opml)")
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("too many arguments")
+	}
+
+	feedFollows, err := s.db.GetFeedFollowsForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	subs := make([]rss.Subscription, 0, len(feedFollows))
+	for _, ff := range feedFollows {
+		subs = append(subs, rss.Subscription{
+			Title:   ff.FeedName,
+			FeedURL: ff.FeedUrl,
+			SiteURL: ff.FeedSiteUrl.String,
+			Folder:  ff.Folder.String,
+		})
+	}
+
+	var w io.Writer = os.Stdout
+	if len(args) == 1 {
+		f, err := os.Create(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	err = rss.WriteOPML(w, fmt.Sprintf("%s's feeds in gator", user.Name), subs)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		fmt.Printf("Exported %d feeds to %s\n", len(subs), args[0])
+	}
+	return nil
+}
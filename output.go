@@ -0,0 +1,279 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aranaris/gator/internal/database"
+)
+
+// outputCommands are the commands that can print their rows with --output.
+var outputCommands = map[string]bool{
+	"users":     true,
+	"feeds":     true,
+	"following": true,
+	"browse":    true,
+}
+
+// extractOutputFlag removes the global --output option from the command
+// line arguments, wherever it appears, and returns its value.
+func extractOutputFlag(args []string) (string, []string, error) {
+	var format string
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--output" || arg == "-output":
+			if i+1 == len(args) {
+				return "", nil, fmt.Errorf("%s needs a value (json, csv or tsv)", arg)
+			}
+			format = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--output=") || strings.HasPrefix(arg, "-output="):
+			_, format, _ = strings.Cut(arg, "=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	switch format {
+	case "", "json", "csv", "tsv":
+		return format, rest, nil
+	}
+	return "", nil, fmt.Errorf("unknown output format %q (expected json, csv or tsv)", format)
+}
+
+// The records below are the stable schema used for --output. Their json
+// tags also name the csv and tsv columns.
+
+type userRecord struct {
+	ID        int64     `json:"id"`
+	CreatedAt timestamp `json:"created_at"`
+	UpdatedAt timestamp `json:"updated_at"`
+	Name      string    `json:"name"`
+	Current   bool      `json:"current"`
+}
+
+type feedRecord struct {
+	ID                  int64      `json:"id"`
+	CreatedAt           timestamp  `json:"created_at"`
+	UpdatedAt           timestamp  `json:"updated_at"`
+	Name                string     `json:"name"`
+	Url                 string     `json:"url"`
+	UserID              int64      `json:"user_id"`
+	Description         *string    `json:"description"`
+	SiteUrl             *string    `json:"site_url"`
+	ImageUrl            *string    `json:"image_url"`
+	LastFetchedAt       *timestamp `json:"last_fetched_at"`
+	LastSucceededAt     *timestamp `json:"last_succeeded_at"`
+	LastError           *string    `json:"last_error"`
+	ConsecutiveFailures int32      `json:"consecutive_failures"`
+	DisabledAt          *timestamp `json:"disabled_at"`
+}
+
+type feedFollowRecord struct {
+	ID          int64     `json:"id"`
+	CreatedAt   timestamp `json:"created_at"`
+	UpdatedAt   timestamp `json:"updated_at"`
+	UserID      int64     `json:"user_id"`
+	FeedID      int64     `json:"feed_id"`
+	Folder      *string   `json:"folder"`
+	FeedName    string    `json:"feed_name"`
+	FeedUrl     string    `json:"feed_url"`
+	FeedSiteUrl *string   `json:"feed_site_url"`
+	Unread      int64     `json:"unread"`
+}
+
+type postRecord struct {
+	ID                   int64      `json:"id"`
+	CreatedAt            timestamp  `json:"created_at"`
+	UpdatedAt            timestamp  `json:"updated_at"`
+	FeedID               int64      `json:"feed_id"`
+	Guid                 string     `json:"guid"`
+	Title                string     `json:"title"`
+	Url                  string     `json:"url"`
+	Description          *string    `json:"description"`
+	Content              *string    `json:"content"`
+	PublishedAt          timestamp  `json:"published_at"`
+	PublishedAtEstimated bool       `json:"published_at_estimated"`
+	RevisedAt            *timestamp `json:"revised_at"`
+}
+
+func newUserRecord(user database.User, current bool) userRecord {
+	return userRecord{
+		ID:        user.ID,
+		CreatedAt: timestamp(user.CreatedAt),
+		UpdatedAt: timestamp(user.UpdatedAt),
+		Name:      user.Name,
+		Current:   current,
+	}
+}
+
+func newFeedRecord(feed database.Feed) feedRecord {
+	return feedRecord{
+		ID:                  feed.ID,
+		CreatedAt:           timestamp(feed.CreatedAt),
+		UpdatedAt:           timestamp(feed.UpdatedAt),
+		Name:                feed.Name,
+		Url:                 feed.Url,
+		UserID:              feed.UserID,
+		Description:         stringOrNil(feed.Description),
+		SiteUrl:             stringOrNil(feed.SiteUrl),
+		ImageUrl:            stringOrNil(feed.ImageUrl),
+		LastFetchedAt:       timeOrNil(feed.LastFetchedAt),
+		LastSucceededAt:     timeOrNil(feed.LastSucceededAt),
+		LastError:           stringOrNil(feed.LastError),
+		ConsecutiveFailures: feed.ConsecutiveFailures,
+		DisabledAt:          timeOrNil(feed.DisabledAt),
+	}
+}
+
+func newFeedFollowRecord(ff database.GetFeedFollowsForUserRow, unread int64) feedFollowRecord {
+	return feedFollowRecord{
+		ID:          ff.ID,
+		CreatedAt:   timestamp(ff.CreatedAt),
+		UpdatedAt:   timestamp(ff.UpdatedAt),
+		UserID:      ff.UserID,
+		FeedID:      ff.FeedID,
+		Folder:      stringOrNil(ff.Folder),
+		FeedName:    ff.FeedName,
+		FeedUrl:     ff.FeedUrl,
+		FeedSiteUrl: stringOrNil(ff.FeedSiteUrl),
+		Unread:      unread,
+	}
+}
+
+func newPostRecord(post database.BrowsePostsForUserRow) postRecord {
+	return postRecord{
+		ID:                   post.ID,
+		CreatedAt:            timestamp(post.CreatedAt),
+		UpdatedAt:            timestamp(post.UpdatedAt),
+		FeedID:               post.FeedID,
+		Guid:                 post.Guid,
+		Title:                post.Title,
+		Url:                  post.Url,
+		Description:          stringOrNil(sanitizeStored(post.Description, post.Url)),
+		Content:              stringOrNil(sanitizeStored(post.Content, post.Url)),
+		PublishedAt:          timestamp(post.PublishedAt),
+		PublishedAtEstimated: post.PublishedAtEstimated,
+		RevisedAt:            timeOrNil(post.RevisedAt),
+	}
+}
+
+func stringOrNil(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	return &v.String
+}
+
+func timeOrNil(v sql.NullTime) *timestamp {
+	if !v.Valid {
+		return nil
+	}
+	t := timestamp(v.Time)
+	return &t
+}
+
+// timestamp is a time written the same way in every format: RFC 3339 in UTC,
+// with as many fractional seconds as the database stored.
+type timestamp time.Time
+
+func (t timestamp) String() string {
+	return time.Time(t).UTC().Format(time.RFC3339Nano)
+}
+
+func (t timestamp) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// writeRecords writes records, a slice of one of the record types above, in
+// the given format. Null values are empty in csv and tsv.
+func writeRecords(w io.Writer, format string, records any) error {
+	v := reflect.ValueOf(records)
+	if format == "json" {
+		if v.IsNil() {
+			records = reflect.MakeSlice(v.Type(), 0, 0).Interface()
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	}
+
+	t := v.Type().Elem()
+	header := make([]string, t.NumField())
+	for i := range header {
+		header[i], _, _ = strings.Cut(t.Field(i).Tag.Get("json"), ",")
+	}
+	rows := [][]string{header}
+	for i := 0; i < v.Len(); i++ {
+		row := make([]string, t.NumField())
+		for j := range row {
+			row[j] = formatCell(v.Index(i).Field(j))
+		}
+		rows = append(rows, row)
+	}
+
+	if format == "tsv" {
+		return writeTSV(w, rows)
+	}
+	cw := csv.NewWriter(w)
+	err := cw.WriteAll(rows)
+	if err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// tsvEscaper escapes cells the way Postgres COPY does in text format, so
+// every row stays on one line and tabs only separate columns.
+var tsvEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"\t", `\t`,
+	"\n", `\n`,
+	"\r", `\r`,
+)
+
+func writeTSV(w io.Writer, rows [][]string) error {
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = tsvEscaper.Replace(cell)
+		}
+		_, err := io.WriteString(w, strings.Join(cells, "\t")+"\n")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatCell(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	switch x := v.Interface().(type) {
+	case timestamp:
+		return x.String()
+	case string:
+		return x
+	case bool:
+		return strconv.FormatBool(x)
+	case int32:
+		return strconv.FormatInt(int64(x), 10)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	}
+	return fmt.Sprint(v.Interface())
+}
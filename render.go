@@ -0,0 +1,379 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	ansiBold      = "\x1b[1m"
+	ansiItalic    = "\x1b[3m"
+	ansiUnderline = "\x1b[4m"
+	ansiReset     = "\x1b[0m"
+)
+
+var ansiCodes = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// hiddenElements are elements whose content is never shown.
+var hiddenElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"template": true,
+	"iframe":   true,
+	"object":   true,
+	"svg":      true,
+	"math":     true,
+	"head":     true,
+	"title":    true,
+}
+
+type listState struct {
+	ordered bool
+	n       int
+}
+
+// renderer converts HTML into wrapped terminal text. Links are replaced by
+// numbered footnotes which are listed after the text.
+type renderer struct {
+	out    strings.Builder
+	width  int
+	styled bool
+
+	words   []string
+	space   bool
+	bullet  string
+	lists   []listState
+	quotes  int
+	pre     int
+	preText strings.Builder
+
+	bold   int
+	italic int
+	under  int
+
+	href  string
+	links []string
+
+	// hidden is the hidden element being skipped, if any.
+	hidden string
+}
+
+func renderHTML(src string, width int, styled bool) string {
+	r := &renderer{width: width, styled: styled}
+
+	// The tokenizer follows the HTML5 rules, so bare '<' characters and
+	// unclosed tags come through as text or are closed implicitly rather
+	// than ending the input early.
+	z := html.NewTokenizer(strings.NewReader(src))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+
+		t := z.Token()
+		if r.hidden != "" {
+			if tt == html.EndTagToken && t.Data == r.hidden {
+				r.hidden = ""
+			}
+			continue
+		}
+
+		switch tt {
+		case html.StartTagToken:
+			if hiddenElements[t.Data] {
+				r.hidden = t.Data
+				continue
+			}
+			r.start(t)
+		case html.SelfClosingTagToken:
+			r.start(t)
+			r.end(t)
+		case html.EndTagToken:
+			r.end(t)
+		case html.TextToken:
+			r.text(stripControl(t.Data))
+		}
+	}
+	r.flush()
+
+	body := strings.TrimSpace(r.out.String())
+	if len(r.links) == 0 {
+		return body + "\n"
+	}
+
+	var b strings.Builder
+	b.WriteString(body)
+	b.WriteString("\n\n")
+	for i, link := range r.links {
+		fmt.Fprintf(&b, "[%d] %s\n", i+1, link)
+	}
+	return b.String()
+}
+
+func (r *renderer) start(t html.Token) {
+	switch name := t.Data; name {
+	case "p", "div", "section", "article", "header", "footer", "figure", "table", "dl":
+		r.block()
+	case "br":
+		r.flush()
+	case "tr", "dt", "dd", "figcaption":
+		r.flush()
+	case "td", "th":
+		if len(r.words) > 0 {
+			r.text(" | ")
+		}
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		r.block()
+		r.bold++
+		if name == "h1" || name == "h2" {
+			r.under++
+		}
+		r.text(strings.Repeat("#", int(name[1]-'0')) + " ")
+	case "ul", "ol":
+		if len(r.lists) == 0 {
+			r.block()
+		} else {
+			r.flush()
+		}
+		r.lists = append(r.lists, listState{ordered: name == "ol"})
+	case "li":
+		r.flush()
+		if len(r.lists) == 0 {
+			r.bullet = "• "
+			break
+		}
+		l := &r.lists[len(r.lists)-1]
+		l.n++
+		if l.ordered {
+			r.bullet = fmt.Sprintf("%d. ", l.n)
+		} else {
+			r.bullet = "• "
+		}
+	case "blockquote":
+		r.block()
+		r.quotes++
+	case "pre":
+		r.block()
+		r.pre++
+	case "code":
+		if r.pre == 0 {
+			r.glue("`")
+		}
+	case "b", "strong":
+		r.bold++
+	case "i", "em", "cite":
+		r.italic++
+	case "u", "ins":
+		r.under++
+	case "a":
+		r.href = attr(t, "href")
+	case "img":
+		if alt := attr(t, "alt"); alt != "" {
+			r.text(" [image: " + alt + "] ")
+		} else {
+			r.text(" [image] ")
+		}
+	case "hr":
+		r.block()
+		r.out.WriteString(r.prefix() + strings.Repeat("─", min(r.width, 40)) + "\n")
+		r.block()
+	}
+}
+
+func (r *renderer) end(t html.Token) {
+	switch name := t.Data; name {
+	case "p", "div", "section", "article", "header", "footer", "figure", "table", "dl":
+		r.block()
+	case "tr", "dt", "dd", "figcaption", "li":
+		r.flush()
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		r.block()
+		r.bold = max(r.bold-1, 0)
+		if name == "h1" || name == "h2" {
+			r.under = max(r.under-1, 0)
+		}
+	case "ul", "ol":
+		r.flush()
+		if len(r.lists) > 0 {
+			r.lists = r.lists[:len(r.lists)-1]
+		}
+		if len(r.lists) == 0 {
+			r.block()
+		}
+	case "blockquote":
+		r.block()
+		r.quotes = max(r.quotes-1, 0)
+	case "pre":
+		r.writePre()
+		r.pre = max(r.pre-1, 0)
+		r.block()
+	case "code":
+		if r.pre == 0 {
+			r.glue("`")
+		}
+	case "b", "strong":
+		r.bold = max(r.bold-1, 0)
+	case "i", "em", "cite":
+		r.italic = max(r.italic-1, 0)
+	case "u", "ins":
+		r.under = max(r.under-1, 0)
+	case "a":
+		if r.href != "" && !strings.HasPrefix(r.href, "#") && !strings.HasPrefix(r.href, "javascript:") {
+			r.links = append(r.links, r.href)
+			r.glue(fmt.Sprintf("[%d]", len(r.links)))
+		}
+		r.href = ""
+	}
+}
+
+// text adds inline text, collapsing whitespace the way a browser would.
+func (r *renderer) text(s string) {
+	if r.pre > 0 {
+		r.preText.WriteString(s)
+		return
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s != "" {
+			r.space = true
+		}
+		return
+	}
+
+	first, _ := utf8.DecodeRuneInString(s)
+	if unicode.IsSpace(first) {
+		r.space = true
+	}
+
+	for i, f := range fields {
+		if i == 0 && !r.space && len(r.words) > 0 {
+			r.words[len(r.words)-1] += r.style(f)
+		} else {
+			r.words = append(r.words, r.style(f))
+		}
+	}
+
+	last, _ := utf8.DecodeLastRuneInString(s)
+	r.space = unicode.IsSpace(last)
+}
+
+// glue appends s to the previous word without a separating space.
+func (r *renderer) glue(s string) {
+	if len(r.words) == 0 || r.space {
+		r.words = append(r.words, s)
+	} else {
+		r.words[len(r.words)-1] += s
+	}
+	r.space = false
+}
+
+func (r *renderer) style(word string) string {
+	if !r.styled || (r.bold == 0 && r.italic == 0 && r.under == 0) {
+		return word
+	}
+
+	var codes string
+	if r.bold > 0 {
+		codes += ansiBold
+	}
+	if r.italic > 0 {
+		codes += ansiItalic
+	}
+	if r.under > 0 {
+		codes += ansiUnderline
+	}
+	return codes + word + ansiReset
+}
+
+func (r *renderer) prefix() string {
+	return strings.Repeat("> ", r.quotes) + strings.Repeat("  ", max(len(r.lists)-1, 0))
+}
+
+// flush wraps the pending words into lines at the current indentation.
+func (r *renderer) flush() {
+	if len(r.words) == 0 {
+		r.bullet = ""
+		return
+	}
+
+	prefix := r.prefix()
+	first := prefix + r.bullet
+	rest := prefix + strings.Repeat(" ", utf8.RuneCountInString(r.bullet))
+
+	line := first
+	lineLen := visibleLen(first)
+	empty := true
+	for _, w := range r.words {
+		wl := visibleLen(w)
+		if !empty && lineLen+1+wl > r.width {
+			r.out.WriteString(line + "\n")
+			line, lineLen, empty = rest, visibleLen(rest), true
+		}
+		if !empty {
+			line += " "
+			lineLen++
+		}
+		line += w
+		lineLen += wl
+		empty = false
+	}
+	r.out.WriteString(line + "\n")
+
+	r.words = nil
+	r.space = false
+	r.bullet = ""
+}
+
+// block ends the current paragraph and leaves one blank line after it.
+func (r *renderer) block() {
+	r.flush()
+	s := r.out.String()
+	if s != "" && !strings.HasSuffix(s, "\n\n") {
+		r.out.WriteString("\n")
+	}
+}
+
+func (r *renderer) writePre() {
+	text := strings.Trim(r.preText.String(), "\n")
+	r.preText.Reset()
+	if text == "" {
+		return
+	}
+
+	prefix := r.prefix() + "    "
+	for _, line := range strings.Split(text, "\n") {
+		r.out.WriteString(strings.TrimRight(prefix+line, " ") + "\n")
+	}
+}
+
+func visibleLen(s string) int {
+	return utf8.RuneCountInString(ansiCodes.ReplaceAllString(s, ""))
+}
+
+func attr(t html.Token, name string) string {
+	for _, a := range t.Attr {
+		if a.Key == name {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+	return ""
+}
+
+// stripControl removes control characters other than whitespace, so feed
+// text cannot send escape sequences to the terminal.
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && !unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aranaris/gator/internal/database"
+)
+
+// searchHighlight matches the words SearchPostsForUser marks in snippets.
+var searchHighlight = regexp.MustCompile(`\*\*(.+?)\*\*`)
+
+func searchHandler(ctx context.Context, s *state, cmd command, user database.User) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	feedURL := fs.String("feed", "", "only search the feed with this URL")
+	since := fs.String("since", "", "only posts published on or after this date (YYYY-MM-DD)")
+	until := fs.String("until", "", "only posts published on or before this date (YYYY-MM-DD)")
+	limit := fs.Int("limit", 10, "show at most this many results")
+
+	args, err := cmd.parseFlags(fs)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments (expected a search query)")
+	}
+
+	searchParams := database.SearchPostsForUserParams{
+		Query:      strings.Join(args, " "),
+		UserID:     user.ID,
+		FeedUrl:    nullString(*feedURL),
+		MaxResults: int32(*limit),
+	}
+	searchParams.Since, err = parseDateFlag("since", *since, false)
+	if err != nil {
+		return err
+	}
+	searchParams.Until, err = parseDateFlag("until", *until, true)
+	if err != nil {
+		return err
+	}
+
+	results, err := s.db.SearchPostsForUser(ctx, searchParams)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		fmt.Printf("No posts match %q.\n", searchParams.Query)
+		return nil
+	}
+
+	styled := isTerminal(os.Stdout)
+	for _, r := range results {
+		date := r.PublishedAt.Format("Jan 02 06")
+		if r.PublishedAtEstimated {
+			date = "~" + date
+		}
+
+		fmt.Printf("- [%d] %s %s: %s\n", r.ID, date, r.FeedName, r.Title)
+		if snippet := formatSnippet(r.Snippet, styled); snippet != "" {
+			fmt.Printf("    %s\n", snippet)
+		}
+	}
+	return nil
+}
+
+// formatSnippet tidies a search snippet for the terminal, turning the
+// highlighted words bold when styled.
+func formatSnippet(snippet string, styled bool) string {
+	snippet = strings.Join(strings.Fields(html.UnescapeString(snippet)), " ")
+	if styled {
+		snippet = searchHighlight.ReplaceAllString(snippet, ansiBold+"$1"+ansiReset)
+	}
+	return snippet
+}
+
+// parseDateFlag parses a YYYY-MM-DD or RFC 3339 date given for the named
+// flag. With endOfDay a bare date covers the whole day, so it can be used
+// as an exclusive upper bound.
+func parseDateFlag(name, value string, endOfDay bool) (sql.NullTime, error) {
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return sql.NullTime{Time: t, Valid: true}, nil
+	}
+
+	t, err = time.Parse("2006-01-02", value)
+	if err != nil {
+		return sql.NullTime{}, fmt.Errorf("invalid --%s date %q (expected YYYY-MM-DD)", name, value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
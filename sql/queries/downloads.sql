@@ -0,0 +1,72 @@
+-- name: UpsertDownloadRule :one
+INSERT INTO download_rules (id, created_at, updated_at, user_id, feed_id, keep_last)
+VALUES (
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6
+)
+ON CONFLICT (user_id, feed_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at, keep_last = EXCLUDED.keep_last
+RETURNING *;
+
+-- name: DeleteDownloadRule :execrows
+DELETE FROM download_rules
+WHERE
+	download_rules.user_id = $1
+	and download_rules.feed_id = $2;
+
+-- name: GetDownloadRulesForUser :many
+SELECT
+	download_rules.*,
+	feeds.name feed_name
+FROM
+	download_rules
+	JOIN feed_follows ON download_rules.feed_id = feed_follows.feed_id
+		AND download_rules.user_id = feed_follows.user_id
+	JOIN feeds ON download_rules.feed_id = feeds.id
+WHERE
+	download_rules.user_id = $1
+ORDER BY
+	feeds.name;
+
+-- name: GetRecentEpisodesForFeed :many
+SELECT
+	post_enclosures.*,
+	posts.title post_title,
+	enclosure_downloads.path download_path,
+	enclosure_downloads.completed_at download_completed_at
+FROM
+	post_enclosures
+	JOIN posts ON post_enclosures.post_id = posts.id
+	LEFT JOIN enclosure_downloads ON enclosure_downloads.enclosure_id = post_enclosures.id
+WHERE
+	posts.feed_id = $1
+	AND (
+		post_enclosures.mime_type IS NULL
+		OR post_enclosures.mime_type LIKE 'audio/%'
+		OR post_enclosures.mime_type LIKE 'video/%'
+	)
+ORDER BY
+	posts.published_at DESC
+LIMIT $2;
+
+-- name: UpsertEnclosureDownload :exec
+INSERT INTO enclosure_downloads (id, created_at, updated_at, enclosure_id, path, bytes, completed_at)
+VALUES (
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7
+)
+ON CONFLICT (enclosure_id) DO UPDATE
+SET
+	updated_at = EXCLUDED.updated_at,
+	path = EXCLUDED.path,
+	bytes = EXCLUDED.bytes,
+	completed_at = EXCLUDED.completed_at;
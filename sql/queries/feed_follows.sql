@@ -1,11 +1,12 @@
 -- name: CreateFeedFollow :one
-with inserted as (INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+with inserted as (INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, folder)
 VALUES (
 	$1,
 	$2,
 	$3,
 	$4,
-	$5
+	$5,
+	$6
 )
 RETURNING *)
 
@@ -21,7 +22,9 @@ FROM
 -- name: GetFeedFollowsForUser :many
 SELECT
 	feed_follows.*,
-	feeds.name feed_name
+	feeds.name feed_name,
+	feeds.url feed_url,
+	feeds.site_url feed_site_url
 FROM
 	feed_follows
 	JOIN feeds on feed_follows.feed_id = feeds.id
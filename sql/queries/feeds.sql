@@ -1,12 +1,15 @@
 -- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, description, site_url, image_url)
 VALUES (
 	$1,
 	$2,
 	$3,
 	$4,
 	$5,
-	$6
+	$6,
+	$7,
+	$8,
+	$9
 )
 RETURNING *;
 
@@ -22,11 +25,59 @@ SET updated_at = current_timestamp, last_fetched_at = current_timestamp
 WHERE feeds.id = $1
 RETURNING *;
 
--- name: GetNextFeedToFetch :one
-SELECT *
-FROM
-(
-SELECT * FROM feeds 
-ORDER BY feeds.last_fetched_at ASC NULLS FIRST 
-) as f
-LIMIT 1;
+-- name: ClaimFeedsToFetch :many
+-- Claimed feeds are leased for lease_seconds so other agg processes skip
+-- them until the fetch has been recorded or the lease has run out. Feeds
+-- that keep failing are retried after an exponentially growing delay of
+-- backoff_seconds * 2^failures, capped at 2^10.
+UPDATE feeds
+SET updated_at = current_timestamp, last_fetched_at = current_timestamp,
+	claimed_until = current_timestamp + make_interval(secs => sqlc.arg(lease_seconds)::float8)
+WHERE feeds.id IN (
+	SELECT id FROM feeds
+	WHERE
+		disabled_at IS NULL
+		AND (claimed_until IS NULL OR claimed_until <= current_timestamp)
+		AND (
+			consecutive_failures = 0
+			OR last_fetched_at IS NULL
+			OR last_fetched_at + make_interval(secs => sqlc.arg(backoff_seconds)::float8 * power(2, least(consecutive_failures, 10))) <= current_timestamp
+		)
+	ORDER BY feeds.last_fetched_at ASC NULLS FIRST
+	LIMIT sqlc.arg(max_feeds)
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: UpdateFeedCacheValidators :exec
+UPDATE feeds
+SET etag = $2, last_modified = $3
+WHERE feeds.id = $1;
+
+-- name: RecordFeedSuccess :exec
+UPDATE feeds
+SET last_error = NULL, consecutive_failures = 0, last_succeeded_at = current_timestamp, claimed_until = NULL
+WHERE feeds.id = $1;
+
+-- name: RecordFeedFailure :one
+UPDATE feeds
+SET last_error = $2, consecutive_failures = consecutive_failures + 1, claimed_until = NULL
+WHERE feeds.id = $1
+RETURNING *;
+
+-- name: GetFeedsWithErrors :many
+SELECT * FROM feeds
+WHERE consecutive_failures > 0
+ORDER BY consecutive_failures DESC, name;
+
+-- name: DisableFeed :one
+UPDATE feeds
+SET updated_at = current_timestamp, disabled_at = current_timestamp
+WHERE feeds.id = $1
+RETURNING *;
+
+-- name: EnableFeed :one
+UPDATE feeds
+SET updated_at = current_timestamp, disabled_at = NULL, consecutive_failures = 0
+WHERE feeds.id = $1
+RETURNING *;
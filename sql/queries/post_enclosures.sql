@@ -0,0 +1,29 @@
+-- name: UpsertPostEnclosure :exec
+INSERT INTO post_enclosures (id, created_at, updated_at, post_id, url, length, mime_type, duration, episode, season, image_url)
+VALUES (
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7,
+	$8,
+	$9,
+	$10,
+	$11
+)
+ON CONFLICT (post_id, url) DO UPDATE
+SET
+	updated_at = EXCLUDED.updated_at,
+	length = EXCLUDED.length,
+	mime_type = EXCLUDED.mime_type,
+	duration = EXCLUDED.duration,
+	episode = EXCLUDED.episode,
+	season = EXCLUDED.season,
+	image_url = EXCLUDED.image_url;
+
+-- name: GetEnclosuresForPosts :many
+SELECT * FROM post_enclosures
+WHERE post_id = ANY(sqlc.arg(post_ids)::bigint[])
+ORDER BY post_id, id;
@@ -0,0 +1,43 @@
+-- name: MarkPostRead :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+VALUES (
+	$1,
+	$2,
+	$3
+)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkPostUnread :execrows
+DELETE FROM post_reads
+WHERE
+	post_reads.user_id = $1
+	AND post_reads.post_id = $2;
+
+-- name: MarkFeedRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT
+	sqlc.arg(user_id)::bigint,
+	posts.id,
+	sqlc.arg(read_at)::timestamp
+FROM
+	posts
+WHERE
+	posts.feed_id = sqlc.arg(feed_id)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: GetUnreadCountsForUser :many
+SELECT
+	feed_follows.feed_id,
+	count(posts.id) unread
+FROM
+	feed_follows
+	LEFT JOIN posts ON posts.feed_id = feed_follows.feed_id
+		AND NOT EXISTS (
+			SELECT 1 FROM post_reads
+			WHERE post_reads.user_id = feed_follows.user_id
+				AND post_reads.post_id = posts.id
+		)
+WHERE
+	feed_follows.user_id = $1
+GROUP BY
+	feed_follows.feed_id;
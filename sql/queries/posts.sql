@@ -1,5 +1,5 @@
--- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+-- name: CreatePost :execrows
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, published_at_estimated, guid, content_hash, content)
 VALUES (
     $1,
     $2,
@@ -8,23 +8,185 @@ VALUES (
 		$5,
 		$6,
 		$7,
-		$8
+		$8,
+		$9,
+		$10,
+		$11,
+		$12
 )
-RETURNING *;
+ON CONFLICT (feed_id, guid) DO NOTHING;
 
--- name: GetPostsForUser :many
+-- name: GetPostByFeedAndGuid :one
+-- Posts are read column by column so search_vector stays in the database.
 SELECT
-	*
+	posts.id,
+	posts.created_at,
+	posts.updated_at,
+	posts.title,
+	posts.url,
+	posts.description,
+	posts.published_at,
+	posts.feed_id,
+	posts.published_at_estimated,
+	posts.guid,
+	posts.content_hash,
+	posts.revised_at,
+	posts.content
+FROM posts
+WHERE feed_id = $1 AND guid = $2;
+
+-- name: GetLegacyPostByFeedAndURL :one
+-- Posts saved before guids were recorded had their url copied into guid by
+-- migration 010.
+SELECT id FROM posts
+WHERE feed_id = $1 AND guid = url AND url = $2;
+
+-- name: UpdatePostGuid :exec
+UPDATE posts
+SET updated_at = current_timestamp, guid = $2
+WHERE posts.id = $1;
+
+-- name: UpdatePostContent :exec
+-- Saves the current title, url, description and content as a revision
+-- before overwriting them.
+WITH revision AS (
+	INSERT INTO post_revisions (id, created_at, post_id, title, url, description, content)
+	SELECT sqlc.arg(revision_id), sqlc.arg(revised_at), posts.id, posts.title, posts.url, posts.description, posts.content
+	FROM posts
+	WHERE posts.id = sqlc.arg(post_id)
+)
+UPDATE posts
+SET
+	title = sqlc.arg(title),
+	url = sqlc.arg(url),
+	description = sqlc.arg(description),
+	content = sqlc.arg(content),
+	content_hash = sqlc.arg(content_hash),
+	updated_at = sqlc.arg(revised_at),
+	revised_at = sqlc.arg(revised_at)
+WHERE posts.id = sqlc.arg(post_id);
+
+-- name: BackfillPostContent :exec
+-- Fills in fields that were not recorded when the post was first saved,
+-- and the sanitized description, without treating the post as revised.
+UPDATE posts
+SET content = $2, content_hash = $3, description = $4
+WHERE posts.id = $1;
+
+-- name: GetPostForUser :one
+-- Posts in feeds the user follows, plus any post they starred.
+SELECT
+	posts.id,
+	posts.created_at,
+	posts.updated_at,
+	posts.title,
+	posts.url,
+	posts.description,
+	posts.published_at,
+	posts.feed_id,
+	posts.published_at_estimated,
+	posts.guid,
+	posts.content_hash,
+	posts.revised_at,
+	posts.content
+FROM
+	posts
+WHERE
+	(posts.id = sqlc.arg(post_id) OR posts.url = sqlc.arg(url))
+	AND (
+		EXISTS (
+			SELECT 1 FROM feed_follows
+			WHERE feed_follows.feed_id = posts.feed_id
+				AND feed_follows.user_id = sqlc.arg(user_id)
+		)
+		OR EXISTS (
+			SELECT 1 FROM user_post_state
+			WHERE user_post_state.post_id = posts.id
+				AND user_post_state.user_id = sqlc.arg(user_id)
+				AND user_post_state.starred_at IS NOT NULL
+		)
+	)
+ORDER BY
+	posts.published_at DESC
+LIMIT 1;
+
+-- name: SearchPostsForUser :many
+-- Ranks matching posts in the user's followed feeds, title matches first.
+-- The snippet is taken from the description with its markup removed and
+-- matched words wrapped in **.
+SELECT
+	posts.id,
+	posts.title,
+	posts.url,
+	posts.published_at,
+	posts.published_at_estimated,
+	feeds.name feed_name,
+	ts_rank(posts.search_vector, websearch_to_tsquery('english', sqlc.arg(query))) rank,
+	ts_headline(
+		'english',
+		regexp_replace(coalesce(posts.description, ''), '<[^>]*>', ' ', 'g'),
+		websearch_to_tsquery('english', sqlc.arg(query)),
+		'StartSel=**, StopSel=**, MaxFragments=2, MaxWords=25, MinWords=10'
+	) snippet
+FROM
+	posts
+	JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+	JOIN feeds ON posts.feed_id = feeds.id
+WHERE
+	feed_follows.user_id = sqlc.arg(user_id)
+	AND posts.search_vector @@ websearch_to_tsquery('english', sqlc.arg(query))
+	AND (sqlc.narg(feed_url)::text IS NULL OR feeds.url = sqlc.narg(feed_url))
+	AND (sqlc.narg(since)::timestamp IS NULL OR posts.published_at >= sqlc.narg(since))
+	AND (sqlc.narg(until)::timestamp IS NULL OR posts.published_at < sqlc.narg(until))
+ORDER BY
+	rank DESC,
+	posts.published_at DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: BrowsePostsForUser :many
+-- Posts in the user's followed feeds, or their starred posts in any feed,
+-- narrowed by the optional filters. Starred posts come most recently starred
+-- first unless oldest_first is set. A null post_limit returns every post.
+SELECT
+	posts.id,
+	posts.created_at,
+	posts.updated_at,
+	posts.title,
+	posts.url,
+	posts.description,
+	posts.published_at,
+	posts.feed_id,
+	posts.published_at_estimated,
+	posts.guid,
+	posts.content_hash,
+	posts.revised_at,
+	posts.content
 FROM
+	posts
+	JOIN feeds ON posts.feed_id = feeds.id
+	LEFT JOIN user_post_state ON user_post_state.post_id = posts.id
+		AND user_post_state.user_id = sqlc.arg(user_id)
+WHERE
 	(
-		SELECT
-			posts.*
-		FROM
-			posts
-			JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
-		WHERE
-			feed_follows.user_id = $1
-		ORDER BY
-			posts.published_at DESC
-	) as p
-LIMIT $2;
+		(sqlc.arg(starred)::bool AND user_post_state.starred_at IS NOT NULL)
+		OR (NOT sqlc.arg(starred)::bool AND EXISTS (
+			SELECT 1 FROM feed_follows
+			WHERE feed_follows.feed_id = posts.feed_id
+				AND feed_follows.user_id = sqlc.arg(user_id)
+		))
+	)
+	AND (NOT sqlc.arg(unread)::bool OR NOT EXISTS (
+		SELECT 1 FROM post_reads
+		WHERE post_reads.user_id = sqlc.arg(user_id)
+			AND post_reads.post_id = posts.id
+	))
+	AND (sqlc.narg(feed_url)::text IS NULL OR feeds.url = sqlc.narg(feed_url))
+	AND (sqlc.narg(since)::timestamp IS NULL OR posts.published_at >= sqlc.narg(since))
+	AND (sqlc.narg(until)::timestamp IS NULL OR posts.published_at < sqlc.narg(until))
+ORDER BY
+	CASE WHEN sqlc.arg(starred)::bool AND NOT sqlc.arg(oldest_first)::bool THEN user_post_state.starred_at END DESC,
+	CASE WHEN sqlc.arg(oldest_first)::bool THEN posts.published_at END ASC,
+	posts.published_at DESC,
+	posts.id
+LIMIT sqlc.narg(post_limit)::int
+OFFSET sqlc.arg(post_offset)::int;
@@ -0,0 +1,17 @@
+-- name: StarPost :exec
+INSERT INTO user_post_state (user_id, post_id, starred_at)
+VALUES (
+	$1,
+	$2,
+	$3
+)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET starred_at = coalesce(user_post_state.starred_at, EXCLUDED.starred_at);
+
+-- name: UnstarPost :execrows
+UPDATE user_post_state
+SET starred_at = NULL
+WHERE
+	user_post_state.user_id = $1
+	AND user_post_state.post_id = $2
+	AND user_post_state.starred_at IS NOT NULL;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN last_error text;
+ALTER TABLE feeds ADD COLUMN consecutive_failures integer not null default 0;
+ALTER TABLE feeds ADD COLUMN last_succeeded_at timestamp;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN last_succeeded_at;
+ALTER TABLE feeds DROP COLUMN consecutive_failures;
+ALTER TABLE feeds DROP COLUMN last_error;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN disabled_at timestamp;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN disabled_at;
@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN content_hash text not null default '';
+ALTER TABLE posts ADD COLUMN revised_at timestamp;
+
+CREATE TABLE post_revisions (
+	id bigserial primary key,
+	created_at timestamp not null,
+	post_id bigserial not null,
+	title text not null,
+	url text not null,
+	description text,
+	CONSTRAINT fk_posts_post_revisions
+		FOREIGN KEY(post_id)
+		REFERENCES posts(id)
+		ON DELETE CASCADE
+	);
+
+-- +goose Down
+DROP TABLE post_revisions;
+ALTER TABLE posts DROP COLUMN revised_at;
+ALTER TABLE posts DROP COLUMN content_hash;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE post_enclosures (
+	id bigserial primary key,
+	created_at timestamp not null,
+	updated_at timestamp not null,
+	post_id bigserial not null,
+	url text not null,
+	length bigint,
+	mime_type text,
+	duration text,
+	episode integer,
+	season integer,
+	image_url text,
+	CONSTRAINT fk_posts_post_enclosures
+		FOREIGN KEY(post_id)
+		REFERENCES posts(id)
+		ON DELETE CASCADE,
+	unique (post_id, url)
+	);
+
+-- +goose Down
+DROP TABLE post_enclosures;
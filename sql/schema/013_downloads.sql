@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TABLE download_rules (
+	id bigserial primary key,
+	created_at timestamp not null,
+	updated_at timestamp not null,
+	user_id bigserial not null,
+	feed_id bigserial not null,
+	keep_last integer not null,
+	CONSTRAINT fk_users_download_rules
+		FOREIGN KEY(user_id)
+		REFERENCES users(id)
+		ON DELETE CASCADE,
+	CONSTRAINT fk_feeds_download_rules
+		FOREIGN KEY(feed_id)
+		REFERENCES feeds(id)
+		ON DELETE CASCADE,
+	unique (user_id, feed_id)
+);
+
+CREATE TABLE enclosure_downloads (
+	id bigserial primary key,
+	created_at timestamp not null,
+	updated_at timestamp not null,
+	enclosure_id bigserial not null unique,
+	path text not null,
+	bytes bigint not null,
+	completed_at timestamp,
+	CONSTRAINT fk_post_enclosures_enclosure_downloads
+		FOREIGN KEY(enclosure_id)
+		REFERENCES post_enclosures(id)
+		ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE enclosure_downloads;
+DROP TABLE download_rules;
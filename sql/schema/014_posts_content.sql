@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN content text;
+ALTER TABLE post_revisions ADD COLUMN content text;
+
+-- +goose Down
+ALTER TABLE post_revisions DROP COLUMN content;
+ALTER TABLE posts DROP COLUMN content;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN description text;
+ALTER TABLE feeds ADD COLUMN site_url text;
+ALTER TABLE feeds ADD COLUMN image_url text;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN image_url;
+ALTER TABLE feeds DROP COLUMN site_url;
+ALTER TABLE feeds DROP COLUMN description;
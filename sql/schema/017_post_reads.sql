@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE post_reads (
+	user_id bigserial not null,
+	post_id bigserial not null,
+	read_at timestamp not null,
+	CONSTRAINT fk_users_post_reads
+		FOREIGN KEY(user_id)
+		REFERENCES users(id)
+		ON DELETE CASCADE,
+	CONSTRAINT fk_posts_post_reads
+		FOREIGN KEY(post_id)
+		REFERENCES posts(id)
+		ON DELETE CASCADE,
+	primary key (user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE post_reads;
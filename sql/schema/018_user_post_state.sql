@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE user_post_state (
+	user_id bigserial not null,
+	post_id bigserial not null,
+	starred_at timestamp,
+	CONSTRAINT fk_users_user_post_state
+		FOREIGN KEY(user_id)
+		REFERENCES users(id)
+		ON DELETE CASCADE,
+	CONSTRAINT fk_posts_user_post_state
+		FOREIGN KEY(post_id)
+		REFERENCES posts(id)
+		ON DELETE CASCADE,
+	primary key (user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE user_post_state;
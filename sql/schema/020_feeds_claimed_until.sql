@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN claimed_until timestamp;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN claimed_until;